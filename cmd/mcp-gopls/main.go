@@ -1,15 +1,57 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"log"
 	"os"
+	"strings"
 
 	"github.com/solatis/mcp-gopls/pkg/server"
 )
 
+// settingFlags collects repeated -gopls-setting flags
+type settingFlags []string
+
+func (f *settingFlags) String() string {
+	return strings.Join(*f, ",")
+}
+
+func (f *settingFlags) Set(value string) error {
+	*f = append(*f, value)
+	return nil
+}
+
 func main() {
-	service, err := server.NewService()
+	var settingsFile string
+	var settings settingFlags
+	flag.StringVar(&settingsFile, "gopls-config", "", "path to a JSON file with gopls settings")
+	flag.Var(&settings, "gopls-setting", "gopls setting as key=value, value may be JSON (repeatable)")
+	flag.Parse()
+
+	cfg := server.Config{
+		GoplsSettings: map[string]any{},
+	}
+
+	if settingsFile != "" {
+		loaded, err := server.LoadGoplsSettings(settingsFile)
+		if err != nil {
+			fmt.Printf("Error loading gopls settings: %v", err)
+			log.Fatalf("Error loading gopls settings: %v", err)
+		}
+		cfg.GoplsSettings = loaded
+	}
+
+	for _, setting := range settings {
+		key, value, err := server.ParseGoplsSetting(setting)
+		if err != nil {
+			fmt.Printf("Error parsing gopls setting: %v", err)
+			log.Fatalf("Error parsing gopls setting: %v", err)
+		}
+		cfg.GoplsSettings[key] = value
+	}
+
+	service, err := server.NewService(cfg)
 	if err != nil {
 		fmt.Printf("Error creating service: %v", err)
 		log.Fatalf("Error creating service: %v", err)
@@ -17,7 +59,9 @@ func main() {
 
 	service.RegisterTools()
 
-	if err := service.Start(); err != nil {
+	err = service.Start()
+	service.Close()
+	if err != nil {
 		fmt.Printf("Error running service: %v", err)
 		log.Fatalf("Error running service: %v", err)
 	}
@@ -0,0 +1,180 @@
+// Package apidoc summarizes the exported API of a Go package from its source,
+// for packages gopls has not loaded, such as those in GOROOT or GOMODCACHE.
+package apidoc
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/doc"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"path/filepath"
+)
+
+// Value is a group of exported constants or variables declared together
+type Value struct {
+	Names       []string `json:"names"`
+	Declaration string   `json:"declaration"`
+	Doc         string   `json:"doc,omitempty"`
+}
+
+// Func is an exported function or method
+type Func struct {
+	Name      string `json:"name"`
+	Signature string `json:"signature"`
+	Doc       string `json:"doc,omitempty"`
+	// Receiver is the receiver type of a method, e.g. "*Client"
+	Receiver string `json:"receiver,omitempty"`
+	// PromotedFrom is the embedded type a promoted method is declared on
+	PromotedFrom string `json:"promoted_from,omitempty"`
+}
+
+// Type is an exported type with its method set and the functions, constants
+// and variables associated with it
+type Type struct {
+	Name         string  `json:"name"`
+	Declaration  string  `json:"declaration"`
+	Doc          string  `json:"doc,omitempty"`
+	Methods      []Func  `json:"methods,omitempty"`
+	Constructors []Func  `json:"constructors,omitempty"`
+	Constants    []Value `json:"constants,omitempty"`
+	Variables    []Value `json:"variables,omitempty"`
+}
+
+// Package is the exported surface of a package. Doc comments are reduced to
+// their first sentence.
+type Package struct {
+	ImportPath string  `json:"import_path"`
+	Name       string  `json:"name"`
+	Dir        string  `json:"dir"`
+	Doc        string  `json:"doc,omitempty"`
+	Constants  []Value `json:"constants,omitempty"`
+	Variables  []Value `json:"variables,omitempty"`
+	Functions  []Func  `json:"functions,omitempty"`
+	Types      []Type  `json:"types,omitempty"`
+}
+
+// Extract parses the given files of the package in dir, typically the GoFiles
+// reported by "go list", and returns its exported API.
+func Extract(importPath, dir string, files []string) (*Package, error) {
+	fset := token.NewFileSet()
+	var parsed []*ast.File
+	for _, name := range files {
+		path := name
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(dir, name)
+		}
+		f, err := parser.ParseFile(fset, path, nil, parser.ParseComments)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", name, err)
+		}
+		parsed = append(parsed, f)
+	}
+
+	docPkg, err := doc.NewFromFiles(fset, parsed, importPath, doc.AllMethods)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read package documentation: %w", err)
+	}
+
+	e := extractor{fset: fset, pkg: docPkg}
+	pkg := &Package{
+		ImportPath: importPath,
+		Name:       docPkg.Name,
+		Dir:        dir,
+		Doc:        docPkg.Synopsis(docPkg.Doc),
+		Constants:  e.values(docPkg.Consts),
+		Variables:  e.values(docPkg.Vars),
+		Functions:  e.funcs(docPkg.Funcs),
+	}
+
+	for _, t := range docPkg.Types {
+		pkg.Types = append(pkg.Types, Type{
+			Name:         t.Name,
+			Declaration:  e.genDecl(t.Decl),
+			Doc:          docPkg.Synopsis(t.Doc),
+			Methods:      e.funcs(t.Methods),
+			Constructors: e.funcs(t.Funcs),
+			Constants:    e.values(t.Consts),
+			Variables:    e.values(t.Vars),
+		})
+	}
+
+	return pkg, nil
+}
+
+type extractor struct {
+	fset *token.FileSet
+	pkg  *doc.Package
+}
+
+func (e extractor) values(values []*doc.Value) []Value {
+	var result []Value
+	for _, v := range values {
+		result = append(result, Value{
+			Names:       v.Names,
+			Declaration: e.genDecl(v.Decl),
+			Doc:         e.pkg.Synopsis(v.Doc),
+		})
+	}
+	return result
+}
+
+func (e extractor) funcs(funcs []*doc.Func) []Func {
+	var result []Func
+	for _, f := range funcs {
+		fn := Func{
+			Name:     f.Name,
+			Doc:      e.pkg.Synopsis(f.Doc),
+			Receiver: f.Recv,
+		}
+		if f.Level > 0 {
+			fn.PromotedFrom = f.Orig
+		}
+
+		if f.Decl != nil {
+			decl := *f.Decl
+			decl.Doc = nil
+			decl.Body = nil
+			fn.Signature = e.print(&decl)
+		}
+		result = append(result, fn)
+	}
+	return result
+}
+
+// genDecl prints a declaration without its doc comments, which are reported
+// separately
+func (e extractor) genDecl(decl *ast.GenDecl) string {
+	if decl == nil {
+		return ""
+	}
+
+	stripped := *decl
+	stripped.Doc = nil
+	stripped.Specs = make([]ast.Spec, len(decl.Specs))
+	for i, spec := range decl.Specs {
+		switch s := spec.(type) {
+		case *ast.TypeSpec:
+			copied := *s
+			copied.Doc = nil
+			stripped.Specs[i] = &copied
+		case *ast.ValueSpec:
+			copied := *s
+			copied.Doc = nil
+			stripped.Specs[i] = &copied
+		default:
+			stripped.Specs[i] = spec
+		}
+	}
+	return e.print(&stripped)
+}
+
+func (e extractor) print(node any) string {
+	var buf bytes.Buffer
+	if err := printer.Fprint(&buf, e.fset, node); err != nil {
+		return ""
+	}
+	return buf.String()
+}
@@ -0,0 +1,149 @@
+package diff
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestUnified(t *testing.T) {
+	tests := []struct {
+		name     string
+		old, new string
+		want     string
+	}{
+		{
+			name: "equal",
+			old:  "a\nb\n",
+			new:  "a\nb\n",
+			want: "",
+		},
+		{
+			name: "replace a line",
+			old:  "a\nb\nc\n",
+			new:  "a\nB\nc\n",
+			want: `--- a/f
++++ b/f
+@@ -1,3 +1,3 @@
+ a
+-b
++B
+ c
+`,
+		},
+		{
+			name: "new file",
+			old:  "",
+			new:  "a\nb\n",
+			want: `--- a/f
++++ b/f
+@@ -0,0 +1,2 @@
++a
++b
+`,
+		},
+		{
+			name: "deleted file",
+			old:  "a\n",
+			new:  "",
+			want: `--- a/f
++++ b/f
+@@ -1,1 +0,0 @@
+-a
+`,
+		},
+		{
+			name: "separate hunks",
+			old:  "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n",
+			new:  "one\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\ntwelve\n",
+			want: `--- a/f
++++ b/f
+@@ -1,4 +1,4 @@
+-1
++one
+ 2
+ 3
+ 4
+@@ -9,4 +9,4 @@
+ 9
+ 10
+ 11
+-12
++twelve
+`,
+		},
+		{
+			name: "close changes share a hunk",
+			old:  "1\n2\n3\n4\n5\n6\n7\n",
+			new:  "one\n2\n3\n4\n5\n6\nseven\n",
+			want: `--- a/f
++++ b/f
+@@ -1,7 +1,7 @@
+-1
++one
+ 2
+ 3
+ 4
+ 5
+ 6
+-7
++seven
+`,
+		},
+		{
+			name: "missing final newline",
+			old:  "a\nb",
+			new:  "a\nb\n",
+			want: `--- a/f
++++ b/f
+@@ -1,2 +1,2 @@
+ a
+-b
+\ No newline at end of file
++b
+`,
+		},
+		{
+			name: "crlf",
+			old:  "a\r\nb\r\n",
+			new:  "a\r\nc\r\n",
+			want: "--- a/f\n+++ b/f\n@@ -1,2 +1,2 @@\n a\r\n-b\r\n+c\r\n",
+		},
+		{
+			name: "multi-byte and astral characters",
+			old:  "héllo\n😀\n",
+			new:  "héllo\n😃\n",
+			want: `--- a/f
++++ b/f
+@@ -1,2 +1,2 @@
+ héllo
+-😀
++😃
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Unified("a/f", "b/f", tt.old, tt.new); got != tt.want {
+				t.Errorf("Unified mismatch\ngot:\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnifiedLargeRegion(t *testing.T) {
+	// Regions beyond maxMatrixCells are reported as entirely replaced
+	var old, new strings.Builder
+	for i := 0; i < 2100; i++ {
+		old.WriteString("old\n")
+		new.WriteString("new\n")
+	}
+
+	got := Unified("a/f", "b/f", old.String(), new.String())
+	if !strings.HasPrefix(got, "--- a/f\n+++ b/f\n@@ -1,2100 +1,2100 @@\n") {
+		t.Errorf("unexpected hunk header: %.60q", got)
+	}
+	if deleted, inserted := strings.Count(got, "-old\n"), strings.Count(got, "+new\n"); deleted != 2100 || inserted != 2100 {
+		t.Errorf("expected every line to be replaced, got %d deleted and %d inserted lines", deleted, inserted)
+	}
+}
@@ -0,0 +1,200 @@
+// Package gitcmd runs git and parses its output, to find out what changed in
+// the working tree of a repository.
+package gitcmd
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// LineRange is a range of 1-indexed lines, End included
+type LineRange struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
+}
+
+// ChangedFile is a file added or modified in the working tree. Deleted files
+// are not reported.
+type ChangedFile struct {
+	// Path is the absolute path of the file
+	Path string `json:"path"`
+	// Lines are the added or modified lines. A line next to removed lines
+	// counts as modified.
+	Lines []LineRange `json:"lines,omitempty"`
+	// New is set for files that are untracked or did not exist at the base
+	New bool `json:"new,omitempty"`
+}
+
+// Contains reports whether a 1-indexed line is one of the changed lines. All
+// lines of a new file are changed.
+func (f ChangedFile) Contains(line int) bool {
+	if f.New {
+		return true
+	}
+	for _, r := range f.Lines {
+		if line >= r.Start && line <= r.End {
+			return true
+		}
+	}
+	return false
+}
+
+// Run runs "git <args>" in dir and returns its standard output
+func Run(ctx context.Context, dir string, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+
+	var outBuf, errBuf bytes.Buffer
+	cmd.Stdout = &outBuf
+	cmd.Stderr = &errBuf
+
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("git %s: %w: %s", args[0], err, strings.TrimSpace(errBuf.String()))
+	}
+	return outBuf.Bytes(), nil
+}
+
+// Toplevel returns the root directory of the repository containing dir
+func Toplevel(ctx context.Context, dir string) (string, error) {
+	out, err := Run(ctx, dir, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return "", err
+	}
+	return filepath.FromSlash(strings.TrimSpace(string(out))), nil
+}
+
+// MergeBase returns the commit where HEAD forked from ref, so that changes
+// made on ref since then are not reported as changes of the working tree
+func MergeBase(ctx context.Context, dir, ref string) (string, error) {
+	out, err := Run(ctx, dir, "merge-base", ref, "HEAD")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// ChangedFiles returns the files of the working tree that differ from base,
+// including uncommitted and untracked files. An empty base means HEAD.
+func ChangedFiles(ctx context.Context, dir, base string) ([]ChangedFile, error) {
+	root, err := Toplevel(ctx, dir)
+	if err != nil {
+		return nil, err
+	}
+	if base == "" {
+		base = "HEAD"
+	}
+
+	out, err := Run(ctx, root, "diff", "--no-color", "--no-ext-diff", "--unified=0", base, "--")
+	if err != nil {
+		return nil, err
+	}
+	files, err := parseDiff(out, root)
+	if err != nil {
+		return nil, err
+	}
+
+	out, err = Run(ctx, root, "ls-files", "--others", "--exclude-standard", "-z")
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range strings.Split(string(out), "\x00") {
+		if name != "" {
+			files = append(files, ChangedFile{Path: filepath.Join(root, filepath.FromSlash(name)), New: true})
+		}
+	}
+
+	return files, nil
+}
+
+// parseDiff parses the output of "git diff --unified=0", whose paths are
+// relative to root
+func parseDiff(out []byte, root string) ([]ChangedFile, error) {
+	var files []ChangedFile
+	var current *ChangedFile
+
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "diff --git "):
+			current = nil
+		case strings.HasPrefix(line, "--- "):
+			if current == nil && line == "--- /dev/null" {
+				files = append(files, ChangedFile{New: true})
+				current = &files[len(files)-1]
+			}
+		case strings.HasPrefix(line, "+++ "):
+			name := strings.TrimPrefix(line, "+++ ")
+			if name == "/dev/null" {
+				// Deleted file
+				current = nil
+				continue
+			}
+			name = strings.TrimPrefix(unquote(name), "b/")
+			if current == nil {
+				files = append(files, ChangedFile{})
+				current = &files[len(files)-1]
+			}
+			current.Path = filepath.Join(root, filepath.FromSlash(name))
+		case strings.HasPrefix(line, "@@ ") && current != nil:
+			r, err := parseHunk(line)
+			if err != nil {
+				return nil, err
+			}
+			if !current.New {
+				current.Lines = append(current.Lines, r)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read git diff output: %w", err)
+	}
+
+	return files, nil
+}
+
+// parseHunk returns the new-side lines of a hunk header such as
+// "@@ -10,2 +10,3 @@ func main() {"
+func parseHunk(header string) (LineRange, error) {
+	fields := strings.Fields(header)
+	if len(fields) < 3 || !strings.HasPrefix(fields[2], "+") {
+		return LineRange{}, fmt.Errorf("invalid hunk header: %q", header)
+	}
+
+	start, count := strings.TrimPrefix(fields[2], "+"), "1"
+	if i := strings.IndexByte(start, ','); i >= 0 {
+		start, count = start[:i], start[i+1:]
+	}
+	first, err := strconv.Atoi(start)
+	if err != nil {
+		return LineRange{}, fmt.Errorf("invalid hunk header: %q", header)
+	}
+	n, err := strconv.Atoi(count)
+	if err != nil {
+		return LineRange{}, fmt.Errorf("invalid hunk header: %q", header)
+	}
+
+	if n == 0 {
+		// Only removed lines: the hunk starts after line first, so both
+		// neighbours of the removal count as modified
+		return LineRange{Start: max(first, 1), End: first + 1}, nil
+	}
+	return LineRange{Start: first, End: first + n - 1}, nil
+}
+
+// unquote removes the quoting git applies to paths with special characters
+func unquote(name string) string {
+	if strings.HasPrefix(name, `"`) {
+		if s, err := strconv.Unquote(name); err == nil {
+			return s
+		}
+	}
+	return name
+}
@@ -0,0 +1,167 @@
+package gitcmd
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// diffOutput is "git diff --unified=0" output with a modified file, a new
+// file, a deleted file, a renamed and edited file, a pure rename and a file
+// whose path git quotes.
+const diffOutput = `diff --git a/server.go b/server.go
+index 3b18e51..a0f2c4d 100644
+--- a/server.go
++++ b/server.go
+@@ -3 +3 @@ import (
+-	"log"
++	"log/slog"
+@@ -10,0 +11,2 @@ type Server struct {
++	addr string
++	port int
+@@ -20,2 +21,0 @@ func (s *Server) Start() error {
+-	log.Print("starting")
+-	log.Print("started")
+diff --git a/handler.go b/handler.go
+new file mode 100644
+index 0000000..e69de29
+--- /dev/null
++++ b/handler.go
+@@ -0,0 +1,3 @@
++package app
++
++func handle() {}
+diff --git a/legacy.go b/legacy.go
+deleted file mode 100644
+index e69de29..0000000
+--- a/legacy.go
++++ /dev/null
+@@ -1,3 +0,0 @@
+-package app
+-
+-func legacy() {}
+diff --git a/util.go b/internal/util.go
+similarity index 90%
+rename from util.go
+rename to internal/util.go
+index 1f2a3b4..5c6d7e8 100644
+--- a/util.go
++++ b/internal/util.go
+@@ -1 +1 @@
+-package app
++package internal
+diff --git a/doc.go b/docs/doc.go
+similarity index 100%
+rename from doc.go
+rename to docs/doc.go
+diff --git "a/na\303\257ve file.go" "b/na\303\257ve file.go"
+index 1f2a3b4..5c6d7e8 100644
+--- "a/na\303\257ve file.go"
++++ "b/na\303\257ve file.go"
+@@ -7,3 +7 @@ func naive() {
+-	a()
+-	b()
+-	c()
++	abc()
+`
+
+func TestParseDiff(t *testing.T) {
+	root := filepath.FromSlash("/src/app")
+
+	files, err := parseDiff([]byte(diffOutput), root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []ChangedFile{
+		{
+			Path:  filepath.Join(root, "server.go"),
+			Lines: []LineRange{{Start: 3, End: 3}, {Start: 11, End: 12}, {Start: 21, End: 22}},
+		},
+		{
+			Path: filepath.Join(root, "handler.go"),
+			New:  true,
+		},
+		{
+			Path:  filepath.Join(root, "internal", "util.go"),
+			Lines: []LineRange{{Start: 1, End: 1}},
+		},
+		{
+			Path:  filepath.Join(root, "naïve file.go"),
+			Lines: []LineRange{{Start: 7, End: 7}},
+		},
+	}
+	if !reflect.DeepEqual(files, want) {
+		t.Errorf("parseDiff =\n%+v\nwant\n%+v", files, want)
+	}
+}
+
+func TestParseDiffEmpty(t *testing.T) {
+	files, err := parseDiff(nil, "/src/app")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 0 {
+		t.Errorf("parseDiff of no output = %+v", files)
+	}
+}
+
+func TestParseHunk(t *testing.T) {
+	tests := []struct {
+		header string
+		want   LineRange
+	}{
+		{"@@ -3 +3 @@", LineRange{Start: 3, End: 3}},
+		{"@@ -10,2 +10,3 @@ func main() {", LineRange{Start: 10, End: 12}},
+		// Pure insertion
+		{"@@ -10,0 +11,2 @@", LineRange{Start: 11, End: 12}},
+		// Pure removal after line 19: lines 19 and 20 surround it
+		{"@@ -20,2 +19,0 @@", LineRange{Start: 19, End: 20}},
+		// Removal at the top of the file
+		{"@@ -1,2 +0,0 @@", LineRange{Start: 1, End: 1}},
+	}
+	for _, tt := range tests {
+		got, err := parseHunk(tt.header)
+		if err != nil {
+			t.Errorf("parseHunk(%q): %v", tt.header, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("parseHunk(%q) = %+v, want %+v", tt.header, got, tt.want)
+		}
+	}
+
+	for _, header := range []string{"@@", "@@ -1 -1 @@", "@@ -1 +x,2 @@", "@@ -1 +1,y @@"} {
+		if _, err := parseHunk(header); err == nil {
+			t.Errorf("parseHunk(%q) succeeded", header)
+		}
+	}
+}
+
+func TestUnquote(t *testing.T) {
+	tests := map[string]string{
+		`b/main.go`:             "b/main.go",
+		`"b/with\ttab.go"`:      "b/with\ttab.go",
+		`"b/na\303\257ve.go"`:   "b/naïve.go",
+		`"b/unterminated.go`:    `"b/unterminated.go`,
+		`b/"quoted" in name.go`: `b/"quoted" in name.go`,
+	}
+	for name, want := range tests {
+		if got := unquote(name); got != want {
+			t.Errorf("unquote(%s) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestChangedFileContains(t *testing.T) {
+	modified := ChangedFile{Lines: []LineRange{{Start: 3, End: 3}, {Start: 11, End: 12}}}
+	for line, want := range map[int]bool{2: false, 3: true, 4: false, 11: true, 12: true, 13: false} {
+		if got := modified.Contains(line); got != want {
+			t.Errorf("Contains(%d) = %v, want %v", line, got, want)
+		}
+	}
+
+	if added := (ChangedFile{New: true}); !added.Contains(100) {
+		t.Error("new file does not contain all its lines")
+	}
+}
@@ -0,0 +1,74 @@
+package gocmd
+
+import (
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Message is a positioned error or warning printed by go build or go vet
+type Message struct {
+	Package string `json:"package"`
+	File    string `json:"file"`
+	Line    int    `json:"line"`
+	Column  int    `json:"column,omitempty"`
+	Text    string `json:"text"`
+	// TypeError is set for type checking errors reported by go vet for
+	// packages that do not compile, as opposed to analyzer findings
+	TypeError bool `json:"type_error,omitempty"`
+}
+
+// messageLine matches "path/file.go:12:5: message" and "file.go:12: message"
+var messageLine = regexp.MustCompile(`^(vet: )?(\S+?\.go):(\d+)(?::(\d+))?: (.*)$`)
+
+// ParseBuildOutput parses the text output of go build or go vet. Package
+// headers ("# import/path"), when printed, attribute the following messages;
+// relative file names are resolved against dir, the directory the command
+// ran in. Unpositioned lines, such as a package that could not be found, are
+// returned separately.
+func ParseBuildOutput(output, dir string) (messages []Message, other []string) {
+	pkg := ""
+	for _, line := range strings.Split(output, "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		if strings.HasPrefix(line, "# ") {
+			pkg = strings.TrimPrefix(line, "# ")
+			// go vet prints "# [import/path]" headers for test variants
+			pkg = strings.Trim(pkg, "[]")
+			continue
+		}
+
+		// Continuation of the previous message, e.g. "have/want" details
+		if strings.HasPrefix(line, "\t") && len(messages) > 0 {
+			messages[len(messages)-1].Text += "\n" + strings.TrimSpace(line)
+			continue
+		}
+
+		m := messageLine.FindStringSubmatch(line)
+		if m == nil {
+			other = append(other, line)
+			continue
+		}
+
+		file := m[2]
+		if !filepath.IsAbs(file) {
+			file = filepath.Join(dir, file)
+		}
+		lineNum, _ := strconv.Atoi(m[3])
+		column, _ := strconv.Atoi(m[4])
+
+		messages = append(messages, Message{
+			Package:   pkg,
+			File:      file,
+			Line:      lineNum,
+			Column:    column,
+			Text:      m[5],
+			TypeError: m[1] != "",
+		})
+	}
+
+	return messages, other
+}
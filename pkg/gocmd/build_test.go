@@ -0,0 +1,92 @@
+package gocmd
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParseBuildOutput(t *testing.T) {
+	dir := filepath.FromSlash("/src/app")
+
+	tests := []struct {
+		name      string
+		output    string
+		wantMsgs  []Message
+		wantOther []string
+	}{
+		{
+			name:   "empty",
+			output: "",
+		},
+		{
+			name: "go build with package headers",
+			output: `go: downloading golang.org/x/sync v0.7.0
+# example.com/app/server
+server/server.go:12:5: undefined: handler
+server/server.go:20:2: cannot use x (variable of type int) as string value in assignment
+# example.com/app/cmd
+cmd/main.go:8:2: "fmt" imported and not used
+`,
+			wantMsgs: []Message{
+				{Package: "example.com/app/server", File: filepath.Join(dir, "server/server.go"), Line: 12, Column: 5, Text: "undefined: handler"},
+				{Package: "example.com/app/server", File: filepath.Join(dir, "server/server.go"), Line: 20, Column: 2, Text: "cannot use x (variable of type int) as string value in assignment"},
+				{Package: "example.com/app/cmd", File: filepath.Join(dir, "cmd/main.go"), Line: 8, Column: 2, Text: `"fmt" imported and not used`},
+			},
+			wantOther: []string{"go: downloading golang.org/x/sync v0.7.0"},
+		},
+		{
+			name: "go vet findings and test variant headers",
+			output: `# example.com/app/server
+# [example.com/app/server]
+server/server.go:30:3: fmt.Printf format %d has arg name of wrong type string
+`,
+			wantMsgs: []Message{
+				{Package: "example.com/app/server", File: filepath.Join(dir, "server/server.go"), Line: 30, Column: 3, Text: "fmt.Printf format %d has arg name of wrong type string"},
+			},
+		},
+		{
+			name: "go vet type errors with continuation lines",
+			output: `# example.com/app/server
+vet: server/server.go:14:9: cannot use s (variable of type *Server) as Handler value in return statement: *Server does not implement Handler (missing method Serve)
+	have serve()
+	want Serve()
+`,
+			wantMsgs: []Message{
+				{Package: "example.com/app/server", File: filepath.Join(dir, "server/server.go"), Line: 14, Column: 9,
+					Text:      "cannot use s (variable of type *Server) as Handler value in return statement: *Server does not implement Handler (missing method Serve)\nhave serve()\nwant Serve()",
+					TypeError: true},
+			},
+		},
+		{
+			name: "absolute paths without column",
+			output: `/src/other/file.go:3: syntax error: unexpected newline
+`,
+			wantMsgs: []Message{
+				{File: "/src/other/file.go", Line: 3, Text: "syntax error: unexpected newline"},
+			},
+		},
+		{
+			name: "unpositioned errors",
+			output: `no required module provides package example.com/missing; to add it:
+	go get example.com/missing
+`,
+			wantOther: []string{
+				"no required module provides package example.com/missing; to add it:",
+				"\tgo get example.com/missing",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			messages, other := ParseBuildOutput(tt.output, dir)
+			if !reflect.DeepEqual(messages, tt.wantMsgs) {
+				t.Errorf("messages = %#v\nwant %#v", messages, tt.wantMsgs)
+			}
+			if !reflect.DeepEqual(other, tt.wantOther) {
+				t.Errorf("other = %q, want %q", other, tt.wantOther)
+			}
+		})
+	}
+}
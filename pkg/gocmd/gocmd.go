@@ -0,0 +1,69 @@
+// Package gocmd runs the go command and parses its output, for features that
+// gopls does not provide through LSP.
+package gocmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"sort"
+)
+
+// Runner runs go commands with the environment and build flags gopls is
+// configured with, so that results match what gopls reports.
+type Runner struct {
+	Env        []string
+	BuildFlags []string
+}
+
+// NewRunner creates a runner from gopls settings, using their "env" and
+// "buildFlags" entries.
+func NewRunner(settings map[string]any) *Runner {
+	r := &Runner{}
+
+	if env, ok := settings["env"].(map[string]any); ok {
+		keys := make([]string, 0, len(env))
+		for k := range env {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			r.Env = append(r.Env, fmt.Sprintf("%s=%v", k, env[k]))
+		}
+	}
+
+	if flags, ok := settings["buildFlags"].([]any); ok {
+		for _, flag := range flags {
+			if s, ok := flag.(string); ok {
+				r.BuildFlags = append(r.BuildFlags, s)
+			}
+		}
+	}
+
+	return r
+}
+
+// Run runs "go <verb> <build flags> <args>" in dir. A non-zero exit status is
+// returned as an error along with the output, since several commands report
+// problems that way.
+func (r *Runner) Run(ctx context.Context, dir, verb string, args ...string) (stdout, stderr []byte, err error) {
+	cmdArgs := append([]string{verb}, r.BuildFlags...)
+	cmdArgs = append(cmdArgs, args...)
+
+	cmd := exec.CommandContext(ctx, "go", cmdArgs...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), r.Env...)
+
+	var outBuf, errBuf bytes.Buffer
+	cmd.Stdout = &outBuf
+	cmd.Stderr = &errBuf
+
+	err = cmd.Run()
+	if err != nil {
+		err = fmt.Errorf("go %s: %w", verb, err)
+	}
+
+	return outBuf.Bytes(), errBuf.Bytes(), err
+}
@@ -0,0 +1,70 @@
+package gocmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Module is the module information reported by "go list -json"
+type Module struct {
+	Path    string `json:"Path"`
+	Version string `json:"Version,omitempty"`
+	Dir     string `json:"Dir,omitempty"`
+	Main    bool   `json:"Main,omitempty"`
+}
+
+// PackageError is an error loading a package
+type PackageError struct {
+	Pos string `json:"Pos,omitempty"`
+	Err string `json:"Err"`
+}
+
+// Package is the subset of "go list -json" output used by the tools
+type Package struct {
+	Dir          string        `json:"Dir"`
+	ImportPath   string        `json:"ImportPath"`
+	Name         string        `json:"Name"`
+	Standard     bool          `json:"Standard,omitempty"`
+	DepOnly      bool          `json:"DepOnly,omitempty"`
+	Module       *Module       `json:"Module,omitempty"`
+	GoFiles      []string      `json:"GoFiles,omitempty"`
+	TestGoFiles  []string      `json:"TestGoFiles,omitempty"`
+	XTestGoFiles []string      `json:"XTestGoFiles,omitempty"`
+	Imports      []string      `json:"Imports,omitempty"`
+	Error        *PackageError `json:"Error,omitempty"`
+}
+
+// ListPackages runs "go list -e -json" for the given patterns in dir. With
+// deps set, the dependencies of the matched packages are listed as well.
+func (r *Runner) ListPackages(ctx context.Context, dir string, deps bool, patterns ...string) ([]Package, error) {
+	args := []string{"-e", "-json"}
+	if deps {
+		args = append(args, "-deps")
+	}
+	args = append(args, patterns...)
+
+	stdout, stderr, err := r.Run(ctx, dir, "list", args...)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", err, strings.TrimSpace(string(stderr)))
+	}
+
+	var packages []Package
+	dec := json.NewDecoder(bytes.NewReader(stdout))
+	for {
+		var pkg Package
+		if err := dec.Decode(&pkg); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, fmt.Errorf("failed to decode go list output: %w", err)
+		}
+		packages = append(packages, pkg)
+	}
+
+	return packages, nil
+}
@@ -0,0 +1,228 @@
+package gocmd
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// TestEvent is an event of the "go test -json" stream, see "go doc test2json"
+type TestEvent struct {
+	Time       time.Time `json:"Time"`
+	Action     string    `json:"Action"`
+	Package    string    `json:"Package"`
+	ImportPath string    `json:"ImportPath"`
+	Test       string    `json:"Test"`
+	Elapsed    float64   `json:"Elapsed"`
+	Output     string    `json:"Output"`
+	// FailedBuild is the ImportPath of the build-output events of a package
+	// whose test binary did not build
+	FailedBuild string `json:"FailedBuild"`
+}
+
+// TestResult is the outcome of a single test, benchmark, fuzz test or example
+type TestResult struct {
+	Package          string   `json:"package"`
+	Name             string   `json:"name"`
+	Status           string   `json:"status"`
+	ElapsedSeconds   float64  `json:"elapsed_seconds"`
+	Output           string   `json:"output,omitempty"`
+	FailureLocations []string `json:"failure_locations,omitempty"`
+}
+
+// PackageResult is the outcome of a package as a whole. Output is kept when
+// the package failed, e.g. because it does not build.
+type PackageResult struct {
+	Package        string  `json:"package"`
+	Status         string  `json:"status"`
+	ElapsedSeconds float64 `json:"elapsed_seconds"`
+	Output         string  `json:"output,omitempty"`
+}
+
+// TestReport summarizes a "go test -json" run
+type TestReport struct {
+	Passed   int             `json:"passed"`
+	Failed   int             `json:"failed"`
+	Skipped  int             `json:"skipped"`
+	Tests    []TestResult    `json:"tests"`
+	Packages []PackageResult `json:"packages"`
+}
+
+// failureLocation matches "file_test.go:12: message" lines written by
+// t.Error and friends, and "/abs/path/file.go:12 +0x1f" panic frames.
+var failureLocation = regexp.MustCompile(`^\s*(\S+\.go):(\d+)`)
+
+// ParseTestEvents reads a "go test -json" stream. packageDirs maps import
+// paths to directories and is used to turn the file names of failure
+// messages into absolute paths.
+func ParseTestEvents(r io.Reader, packageDirs map[string]string) (*TestReport, error) {
+	type key struct{ pkg, test string }
+
+	report := &TestReport{}
+	tests := make(map[key]int)
+	packages := make(map[string]int)
+	outputs := make(map[key]*strings.Builder)
+	failedBuilds := make(map[string]string)
+
+	output := func(k key) *strings.Builder {
+		b, ok := outputs[k]
+		if !ok {
+			b = &strings.Builder{}
+			outputs[k] = b
+		}
+		return b
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 || line[0] != '{' {
+			continue
+		}
+
+		var event TestEvent
+		if err := json.Unmarshal(line, &event); err != nil {
+			continue
+		}
+
+		pkg := event.Package
+		if pkg == "" {
+			pkg = event.ImportPath
+		}
+		k := key{pkg, event.Test}
+
+		switch event.Action {
+		case "output", "build-output":
+			output(k).WriteString(event.Output)
+		case "run":
+			if event.Test != "" {
+				if _, ok := tests[k]; !ok {
+					tests[k] = len(report.Tests)
+					report.Tests = append(report.Tests, TestResult{Package: pkg, Name: event.Test, Status: "running"})
+				}
+			}
+		case "pass", "fail", "skip":
+			if event.Test == "" {
+				if _, ok := packages[pkg]; !ok {
+					packages[pkg] = len(report.Packages)
+					report.Packages = append(report.Packages, PackageResult{Package: pkg})
+				}
+				p := &report.Packages[packages[pkg]]
+				p.Status = event.Action
+				p.ElapsedSeconds = event.Elapsed
+				if event.FailedBuild != "" {
+					failedBuilds[pkg] = event.FailedBuild
+				}
+				continue
+			}
+
+			i, ok := tests[k]
+			if !ok {
+				i = len(report.Tests)
+				tests[k] = i
+				report.Tests = append(report.Tests, TestResult{Package: pkg, Name: event.Test})
+			}
+			report.Tests[i].Status = event.Action
+			report.Tests[i].ElapsedSeconds = event.Elapsed
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range report.Tests {
+		t := &report.Tests[i]
+		out := ""
+		if b, ok := outputs[key{t.Package, t.Name}]; ok {
+			out = b.String()
+		}
+
+		switch t.Status {
+		case "pass":
+			report.Passed++
+			if strings.HasPrefix(t.Name, "Benchmark") {
+				t.Output = benchmarkLines(out)
+			}
+		case "skip":
+			report.Skipped++
+			t.Output = out
+		default:
+			// Tests still "running" at the end were interrupted, e.g. by a
+			// panic or a timeout of the test binary
+			if t.Status != "fail" {
+				t.Status = "fail"
+			}
+			report.Failed++
+			t.Output = out
+			t.FailureLocations = failureLocations(out, packageDirs[t.Package])
+		}
+	}
+
+	for i := range report.Packages {
+		p := &report.Packages[i]
+		if p.Status != "fail" {
+			continue
+		}
+		// Compiler errors are reported under the import path of the test
+		// binary, such as "example.com/app [example.com/app.test]"
+		if build, ok := failedBuilds[p.Package]; ok {
+			if b, ok := outputs[key{build, ""}]; ok {
+				p.Output = b.String()
+			}
+		}
+		if b, ok := outputs[key{p.Package, ""}]; ok {
+			p.Output += b.String()
+		}
+	}
+
+	return report, nil
+}
+
+// benchmarkLines keeps only the result lines of benchmark output
+func benchmarkLines(output string) string {
+	var lines []string
+	for _, line := range strings.Split(output, "\n") {
+		if strings.HasPrefix(line, "Benchmark") && strings.Contains(line, "/op") {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func failureLocations(output, dir string) []string {
+	var locations []string
+	seen := make(map[string]bool)
+
+	for _, line := range strings.Split(output, "\n") {
+		m := failureLocation.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+
+		file := m[1]
+		if !filepath.IsAbs(file) {
+			if dir == "" {
+				continue
+			}
+			file = filepath.Join(dir, file)
+		}
+		// Skip frames of the runtime and testing packages in panics
+		if strings.Contains(file, string(filepath.Separator)+"src"+string(filepath.Separator)+"testing"+string(filepath.Separator)) ||
+			strings.Contains(file, string(filepath.Separator)+"src"+string(filepath.Separator)+"runtime"+string(filepath.Separator)) {
+			continue
+		}
+
+		location := file + ":" + m[2]
+		if !seen[location] {
+			seen[location] = true
+			locations = append(locations, location)
+		}
+	}
+
+	return locations
+}
@@ -0,0 +1,131 @@
+package gocmd
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// testStream is "go test -json" output of two packages running in parallel,
+// with interleaved events, a failing test, a subtest, a skipped test, a
+// benchmark and a package that does not build.
+const testStream = `{"Action":"start","Package":"example.com/app/a"}
+{"Action":"start","Package":"example.com/app/b"}
+{"Action":"run","Package":"example.com/app/a","Test":"TestAdd"}
+{"Action":"output","Package":"example.com/app/a","Test":"TestAdd","Output":"=== RUN   TestAdd\n"}
+{"Action":"run","Package":"example.com/app/b","Test":"TestAdd"}
+{"Action":"output","Package":"example.com/app/b","Test":"TestAdd","Output":"=== RUN   TestAdd\n"}
+{"Action":"output","Package":"example.com/app/a","Test":"TestAdd","Output":"    add_test.go:12: got 3, want 4\n"}
+{"Action":"output","Package":"example.com/app/b","Test":"TestAdd","Output":"--- PASS: TestAdd (0.00s)\n"}
+{"Action":"pass","Package":"example.com/app/b","Test":"TestAdd","Elapsed":0.01}
+{"Action":"output","Package":"example.com/app/a","Test":"TestAdd","Output":"--- FAIL: TestAdd (0.00s)\n"}
+{"Action":"fail","Package":"example.com/app/a","Test":"TestAdd","Elapsed":0.02}
+{"Action":"run","Package":"example.com/app/a","Test":"TestTable"}
+{"Action":"run","Package":"example.com/app/a","Test":"TestTable/empty"}
+{"Action":"output","Package":"example.com/app/a","Test":"TestTable/empty","Output":"    --- PASS: TestTable/empty (0.00s)\n"}
+{"Action":"pass","Package":"example.com/app/a","Test":"TestTable/empty","Elapsed":0}
+{"Action":"pass","Package":"example.com/app/a","Test":"TestTable","Elapsed":0}
+{"Action":"run","Package":"example.com/app/a","Test":"TestNetwork"}
+{"Action":"output","Package":"example.com/app/a","Test":"TestNetwork","Output":"    net_test.go:8: no network\n"}
+{"Action":"skip","Package":"example.com/app/a","Test":"TestNetwork","Elapsed":0}
+{"Action":"run","Package":"example.com/app/b","Test":"BenchmarkAdd"}
+{"Action":"output","Package":"example.com/app/b","Test":"BenchmarkAdd","Output":"BenchmarkAdd\n"}
+{"Action":"output","Package":"example.com/app/b","Test":"BenchmarkAdd","Output":"BenchmarkAdd-8   \t1000000000\t         0.25 ns/op\n"}
+{"Action":"pass","Package":"example.com/app/b","Test":"BenchmarkAdd","Elapsed":1.2}
+{"Action":"output","Package":"example.com/app/a","Output":"FAIL\n"}
+{"Action":"fail","Package":"example.com/app/a","Elapsed":0.5}
+{"Action":"pass","Package":"example.com/app/b","Elapsed":1.3}
+{"ImportPath":"example.com/app/c [example.com/app/c.test]","Action":"build-output","Output":"# example.com/app/c [example.com/app/c.test]\n"}
+{"ImportPath":"example.com/app/c [example.com/app/c.test]","Action":"build-output","Output":"c/c_test.go:5:2: undefined: missing\n"}
+{"ImportPath":"example.com/app/c [example.com/app/c.test]","Action":"build-fail"}
+{"Action":"start","Package":"example.com/app/c"}
+{"Action":"output","Package":"example.com/app/c","Output":"FAIL\texample.com/app/c [build failed]\n"}
+{"Action":"fail","Package":"example.com/app/c","Elapsed":0,"FailedBuild":"example.com/app/c [example.com/app/c.test]"}
+ok  	not a json line
+`
+
+func TestParseTestEvents(t *testing.T) {
+	dirA := filepath.FromSlash("/src/app/a")
+
+	report, err := ParseTestEvents(strings.NewReader(testStream), map[string]string{"example.com/app/a": dirA})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if report.Passed != 4 || report.Failed != 1 || report.Skipped != 1 {
+		t.Errorf("passed, failed, skipped = %d, %d, %d, want 4, 1, 1", report.Passed, report.Failed, report.Skipped)
+	}
+
+	type summary struct{ pkg, name, status string }
+	var got []summary
+	for _, test := range report.Tests {
+		got = append(got, summary{test.Package, test.Name, test.Status})
+	}
+	want := []summary{
+		{"example.com/app/a", "TestAdd", "fail"},
+		{"example.com/app/b", "TestAdd", "pass"},
+		{"example.com/app/a", "TestTable", "pass"},
+		{"example.com/app/a", "TestTable/empty", "pass"},
+		{"example.com/app/a", "TestNetwork", "skip"},
+		{"example.com/app/b", "BenchmarkAdd", "pass"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("tests = %v\nwant %v", got, want)
+	}
+
+	failed := report.Tests[0]
+	if !strings.Contains(failed.Output, "got 3, want 4") {
+		t.Errorf("failed test output = %q", failed.Output)
+	}
+	if wantLocations := []string{filepath.Join(dirA, "add_test.go") + ":12"}; !reflect.DeepEqual(failed.FailureLocations, wantLocations) {
+		t.Errorf("failure locations = %v, want %v", failed.FailureLocations, wantLocations)
+	}
+	if report.Tests[1].Output != "" {
+		t.Errorf("passing test kept its output: %q", report.Tests[1].Output)
+	}
+	if report.Tests[4].Output != "    net_test.go:8: no network\n" {
+		t.Errorf("skipped test output = %q", report.Tests[4].Output)
+	}
+	if want := "BenchmarkAdd-8   \t1000000000\t         0.25 ns/op"; report.Tests[5].Output != want {
+		t.Errorf("benchmark output = %q, want %q", report.Tests[5].Output, want)
+	}
+
+	var packages []summary
+	for _, pkg := range report.Packages {
+		packages = append(packages, summary{pkg.Package, "", pkg.Status})
+	}
+	wantPackages := []summary{
+		{"example.com/app/a", "", "fail"},
+		{"example.com/app/b", "", "pass"},
+		{"example.com/app/c", "", "fail"},
+	}
+	if !reflect.DeepEqual(packages, wantPackages) {
+		t.Errorf("packages = %v\nwant %v", packages, wantPackages)
+	}
+	if out := report.Packages[2].Output; !strings.Contains(out, "undefined: missing") || !strings.Contains(out, "[build failed]") {
+		t.Errorf("build failure output = %q", out)
+	}
+}
+
+func TestParseTestEventsInterrupted(t *testing.T) {
+	// A panic ends the test binary before the test reports a result
+	stream := `{"Action":"run","Package":"example.com/app/a","Test":"TestPanic"}
+{"Action":"output","Package":"example.com/app/a","Test":"TestPanic","Output":"panic: boom\n"}
+{"Action":"output","Package":"example.com/app/a","Test":"TestPanic","Output":"\t/usr/local/go/src/testing/testing.go:1689 +0x21\n"}
+{"Action":"output","Package":"example.com/app/a","Test":"TestPanic","Output":"\t/src/app/a/panic_test.go:7 +0x18\n"}
+{"Action":"fail","Package":"example.com/app/a","Elapsed":0.1}
+`
+
+	report, err := ParseTestEvents(strings.NewReader(stream), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(report.Tests) != 1 || report.Tests[0].Status != "fail" || report.Failed != 1 {
+		t.Fatalf("tests = %+v", report.Tests)
+	}
+	if want := []string{filepath.FromSlash("/src/app/a/panic_test.go") + ":7"}; !reflect.DeepEqual(report.Tests[0].FailureLocations, want) {
+		t.Errorf("failure locations = %v, want %v", report.Tests[0].FailureLocations, want)
+	}
+}
@@ -0,0 +1,65 @@
+package client
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/solatis/mcp-gopls/pkg/lsp/protocol"
+)
+
+// PrepareCallHierarchy returns the call hierarchy items of the function or
+// method at a position, to pass to IncomingCalls
+func (c *GoplsClient) PrepareCallHierarchy(uri string, line, character int) ([]protocol.CallHierarchyItem, error) {
+	log.Printf("🔍 Preparing call hierarchy for %s position L%d:C%d", uri, line, character)
+
+	if err := c.DidOpen(uri, "go", ""); err != nil {
+		log.Printf("⚠️ Warning opening document: %v", err)
+	}
+
+	// gopls has analyzed the document once it published its diagnostics
+	if _, ok := c.waitForDiagnostics(uri, c.documentVersion(uri), diagnosticsTimeout); !ok {
+		log.Printf("⚠️ No diagnostics published for %s after %v, preparing call hierarchy anyway", uri, diagnosticsTimeout)
+	}
+
+	params := protocol.TextDocumentPositionParams{
+		TextDocument: protocol.TextDocumentIdentifier{URI: uri},
+		Position:     protocol.Position{Line: line, Character: character},
+	}
+
+	resp, err := c.call("textDocument/prepareCallHierarchy", params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare call hierarchy: %w", err)
+	}
+
+	if resp == nil || len(resp.Result) == 0 || string(resp.Result) == "null" {
+		return []protocol.CallHierarchyItem{}, nil
+	}
+
+	var items []protocol.CallHierarchyItem
+	if err := resp.ParseResult(&items); err != nil {
+		return nil, fmt.Errorf("failed to decode call hierarchy items: %w", err)
+	}
+
+	return items, nil
+}
+
+// IncomingCalls returns the functions calling an item
+func (c *GoplsClient) IncomingCalls(item protocol.CallHierarchyItem) ([]protocol.CallHierarchyIncomingCall, error) {
+	log.Printf("🔍 Requesting incoming calls of %s", item.Name)
+
+	resp, err := c.call("callHierarchy/incomingCalls", map[string]any{"item": item})
+	if err != nil {
+		return nil, fmt.Errorf("failed to request incoming calls: %w", err)
+	}
+
+	if resp == nil || len(resp.Result) == 0 || string(resp.Result) == "null" {
+		return []protocol.CallHierarchyIncomingCall{}, nil
+	}
+
+	var calls []protocol.CallHierarchyIncomingCall
+	if err := resp.ParseResult(&calls); err != nil {
+		return nil, fmt.Errorf("failed to decode incoming calls: %w", err)
+	}
+
+	return calls, nil
+}
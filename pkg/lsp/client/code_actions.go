@@ -0,0 +1,113 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+
+	"github.com/solatis/mcp-gopls/pkg/lsp/protocol"
+)
+
+// CodeActions requests the code actions for a range of a document, restricted
+// to the given kinds (e.g. "refactor.extract") when any are given. Bare
+// commands returned by the server are wrapped in code actions.
+func (c *GoplsClient) CodeActions(uri string, rng protocol.Range, only ...string) ([]protocol.CodeAction, error) {
+	log.Printf("🔍 Requesting code actions for %s range L%d:C%d-L%d:C%d", uri, rng.Start.Line, rng.Start.Character, rng.End.Line, rng.End.Character)
+
+	if err := c.DidOpen(uri, "go", ""); err != nil {
+		log.Printf("⚠️ Warning opening document: %v", err)
+	}
+
+	// Diagnostics overlapping the range enable the matching quick fixes, so
+	// wait for those of the current version of the document
+	published, ok := c.waitForDiagnostics(uri, c.documentVersion(uri), diagnosticsTimeout)
+	if !ok {
+		log.Printf("⚠️ No diagnostics published for %s after %v, requesting code actions anyway", uri, diagnosticsTimeout)
+	}
+	var diagnostics []protocol.Diagnostic
+	for _, d := range published {
+		if d.Range.Start.Line <= rng.End.Line && d.Range.End.Line >= rng.Start.Line {
+			diagnostics = append(diagnostics, d)
+		}
+	}
+	if diagnostics == nil {
+		diagnostics = []protocol.Diagnostic{}
+	}
+
+	params := protocol.CodeActionParams{
+		TextDocument: protocol.TextDocumentIdentifier{URI: uri},
+		Range:        rng,
+		Context: protocol.CodeActionContext{
+			Diagnostics: diagnostics,
+			Only:        only,
+		},
+	}
+
+	resp, err := c.call("textDocument/codeAction", params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to request code actions: %w", err)
+	}
+
+	if resp == nil || len(resp.Result) == 0 || string(resp.Result) == "null" {
+		return []protocol.CodeAction{}, nil
+	}
+
+	var items []json.RawMessage
+	if err := resp.ParseResult(&items); err != nil {
+		return nil, fmt.Errorf("failed to decode code actions: %w", err)
+	}
+
+	actions := make([]protocol.CodeAction, 0, len(items))
+	for _, item := range items {
+		// A Command has a string "command" field, a CodeAction an object
+		var command protocol.Command
+		if err := json.Unmarshal(item, &command); err == nil && command.Command != "" {
+			actions = append(actions, protocol.CodeAction{Title: command.Title, Command: &command})
+			continue
+		}
+
+		var action protocol.CodeAction
+		if err := json.Unmarshal(item, &action); err != nil {
+			return nil, fmt.Errorf("failed to decode code action: %w", err)
+		}
+		actions = append(actions, action)
+	}
+
+	return actions, nil
+}
+
+// ApplyCodeAction performs a code action: it is resolved first when the server
+// deferred computing it, then its edit is applied and its command executed.
+func (c *GoplsClient) ApplyCodeAction(action protocol.CodeAction) error {
+	log.Printf("✏️ Applying code action: %s", action.Title)
+
+	if action.Edit == nil && action.Command == nil && len(action.Data) > 0 {
+		resp, err := c.call("codeAction/resolve", action)
+		if err != nil {
+			return fmt.Errorf("failed to resolve code action: %w", err)
+		}
+		if err := resp.ParseResult(&action); err != nil {
+			return fmt.Errorf("failed to decode resolved code action: %w", err)
+		}
+	}
+
+	if action.Edit != nil {
+		if err := c.ApplyWorkspaceEdit(*action.Edit); err != nil {
+			return err
+		}
+	}
+
+	if action.Command != nil {
+		// The server applies the changes of the command through
+		// workspace/applyEdit requests
+		arguments := make([]any, len(action.Command.Arguments))
+		for i, argument := range action.Command.Arguments {
+			arguments[i] = argument
+		}
+		if _, err := c.ExecuteCommand(action.Command.Command, arguments...); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,111 @@
+package client
+
+import (
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/solatis/mcp-gopls/pkg/lsp/protocol"
+)
+
+// diagnosticsTimeout is how long GetDiagnostics waits for gopls to publish
+// diagnostics for a document. The first analysis of a module can be slow.
+const diagnosticsTimeout = 15 * time.Second
+
+// publishedDiagnostics holds the last diagnostics gopls published for a document
+type publishedDiagnostics struct {
+	Version     int
+	Diagnostics []protocol.Diagnostic
+}
+
+func (c *GoplsClient) handlePublishDiagnostics(msg *protocol.JSONRPCMessage) {
+	var params struct {
+		URI         string                `json:"uri"`
+		Version     int                   `json:"version,omitempty"`
+		Diagnostics []protocol.Diagnostic `json:"diagnostics"`
+	}
+	if err := json.Unmarshal(msg.Params, &params); err != nil {
+		log.Printf("⚠️ Invalid publishDiagnostics notification: %v", err)
+		return
+	}
+
+	log.Printf("🩺 %d diagnostics published for %s (version %d)", len(params.Diagnostics), params.URI, params.Version)
+
+	c.diagnosticsMutex.Lock()
+	defer c.diagnosticsMutex.Unlock()
+
+	c.diagnostics[params.URI] = publishedDiagnostics{
+		Version:     params.Version,
+		Diagnostics: params.Diagnostics,
+	}
+
+	close(c.diagnosticsUpdated)
+	c.diagnosticsUpdated = make(chan struct{})
+}
+
+// waitForDiagnostics waits until gopls has published diagnostics for the given
+// version of an open document. On timeout, the last known diagnostics are
+// returned along with false.
+func (c *GoplsClient) waitForDiagnostics(uri string, version int, timeout time.Duration) ([]protocol.Diagnostic, bool) {
+	deadline := time.After(timeout)
+	for {
+		c.diagnosticsMutex.Lock()
+		published, ok := c.diagnostics[uri]
+		updated := c.diagnosticsUpdated
+		c.diagnosticsMutex.Unlock()
+
+		if ok && published.Version >= version {
+			return published.Diagnostics, true
+		}
+
+		select {
+		case <-updated:
+		case <-deadline:
+			return published.Diagnostics, false
+		}
+	}
+}
+
+// WorkspaceDiagnostics returns the last diagnostics gopls published for every
+// file, open or not, leaving out files without diagnostics.
+func (c *GoplsClient) WorkspaceDiagnostics() map[string][]protocol.Diagnostic {
+	c.diagnosticsMutex.Lock()
+	defer c.diagnosticsMutex.Unlock()
+
+	all := make(map[string][]protocol.Diagnostic, len(c.diagnostics))
+	for uri, published := range c.diagnostics {
+		if len(published.Diagnostics) > 0 {
+			all[uri] = published.Diagnostics
+		}
+	}
+	return all
+}
+
+// DiagnosticsUpdated returns a channel closed the next time gopls publishes
+// diagnostics for any document
+func (c *GoplsClient) DiagnosticsUpdated() <-chan struct{} {
+	c.diagnosticsMutex.Lock()
+	defer c.diagnosticsMutex.Unlock()
+	return c.diagnosticsUpdated
+}
+
+// WaitForDiagnosticsIdle waits until gopls has not published diagnostics for
+// the quiet duration, or until timeout. gopls diagnoses a workspace package by
+// package after loading it, so this lets the analysis settle.
+func (c *GoplsClient) WaitForDiagnosticsIdle(quiet, timeout time.Duration) {
+	deadline := time.After(timeout)
+	for {
+		c.diagnosticsMutex.Lock()
+		updated := c.diagnosticsUpdated
+		c.diagnosticsMutex.Unlock()
+
+		select {
+		case <-updated:
+		case <-time.After(quiet):
+			return
+		case <-deadline:
+			log.Printf("⚠️ Diagnostics still being published after %v", timeout)
+			return
+		}
+	}
+}
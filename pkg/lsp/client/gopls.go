@@ -3,11 +3,11 @@ package client
 import (
 	"bufio"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"os"
 	"os/exec"
-	"runtime"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -23,9 +23,31 @@ type GoplsClient struct {
 	closed      atomic.Bool
 	mutex       sync.Mutex
 	initialized bool
+
+	pending      map[int64]chan *protocol.JSONRPCMessage
+	pendingMutex sync.Mutex
+
+	settings      map[string]any
+	settingsMutex sync.RWMutex
+	// symbolMutex keeps workspace symbol queries from running while the
+	// settings of another query are applied
+	symbolMutex sync.RWMutex
+
+	openDocs map[string]string
+	versions map[string]int
+	// overlays are the documents whose content was set by SetOverlay and
+	// differs from the disk until ClearOverlay, which closes the channel
+	overlays  map[string]chan struct{}
+	docsMutex sync.Mutex
+
+	diagnostics        map[string]publishedDiagnostics
+	diagnosticsUpdated chan struct{}
+	diagnosticsMutex   sync.Mutex
 }
 
-func NewGoplsClient() (*GoplsClient, error) {
+// NewGoplsClient starts a gopls process. The given settings are sent as
+// initializationOptions and served on workspace/configuration requests.
+func NewGoplsClient(settings map[string]any) (*GoplsClient, error) {
 	goplsPath, err := exec.LookPath("gopls")
 	if err != nil {
 		return nil, fmt.Errorf("gopls is not installed or not in PATH: %w", err)
@@ -85,9 +107,21 @@ func NewGoplsClient() (*GoplsClient, error) {
 		transport:   transport,
 		nextID:      1,
 		initialized: false,
+		pending:     make(map[int64]chan *protocol.JSONRPCMessage),
+		settings:    make(map[string]any, len(settings)),
+		openDocs:    make(map[string]string),
+		versions:    make(map[string]int),
+		overlays:    make(map[string]chan struct{}),
+
+		diagnostics:        make(map[string]publishedDiagnostics),
+		diagnosticsUpdated: make(chan struct{}),
+	}
+	for k, v := range settings {
+		client.settings[k] = v
 	}
 
 	client.closed.Store(false)
+	go client.readLoop()
 
 	log.Printf("✅ Gopls client created successfully")
 	return client, nil
@@ -117,6 +151,17 @@ func (c *GoplsClient) call(method string, params any) (*protocol.JSONRPCMessage,
 	}
 	log.Println("✓ Request created")
 
+	respCh := make(chan *protocol.JSONRPCMessage, 1)
+	c.pendingMutex.Lock()
+	c.pending[id] = respCh
+	c.pendingMutex.Unlock()
+
+	defer func() {
+		c.pendingMutex.Lock()
+		delete(c.pending, id)
+		c.pendingMutex.Unlock()
+	}()
+
 	if err := c.transport.SendMessage(req); err != nil {
 		c.closed.Store(true)
 		c.mutex.Unlock()
@@ -125,52 +170,176 @@ func (c *GoplsClient) call(method string, params any) (*protocol.JSONRPCMessage,
 	}
 	c.mutex.Unlock()
 
-	startTime := time.Now()
 	maxWaitTime := 30 * time.Second
-	for time.Since(startTime) < maxWaitTime {
-		resp, err := c.transport.ReceiveMessage()
+	select {
+	case resp, ok := <-respCh:
+		if !ok {
+			return nil, fmt.Errorf("failed to receive response (client closed)")
+		}
+
+		respBytes, _ := json.MarshalIndent(resp, "", "  ")
+		log.Printf("📥 Response content: %s", string(respBytes))
+
+		if resp.Error != nil {
+			return nil, fmt.Errorf("LSP error: %s (code: %d)", resp.Error.Message, resp.Error.Code)
+		}
+
+		return resp, nil
+	case <-time.After(maxWaitTime):
+		return nil, fmt.Errorf("timeout: no response with matching ID after %v seconds", maxWaitTime.Seconds())
+	}
+}
+
+// readLoop reads every message sent by gopls and dispatches it: responses go
+// to the pending call with the same ID, server requests are answered and
+// notifications are handled in place.
+func (c *GoplsClient) readLoop() {
+	for {
+		msg, err := c.transport.ReadMessage()
 		if err != nil {
-			if strings.Contains(err.Error(), "timeout") {
-				return nil, fmt.Errorf("timeout receiving response: %w", err)
-			}
-			c.closed.Store(true)
-			return nil, fmt.Errorf("failed to receive response (client closed): %w", err)
-		}
-
-		var respID int64
-		switch v := resp.ID.(type) {
-		case float64:
-			respID = int64(v)
-		case int64:
-			respID = v
-		case json.Number:
-			respID64, err := v.Int64()
-			if err != nil {
-				log.Printf("⚠️ Invalid ID format in response: %v", resp.ID)
+			if !c.transport.IsClosed() {
+				log.Printf("⚠️ Error reading message: %v", err)
 				continue
 			}
-			respID = respID64
+			log.Printf("❌ Connection to gopls lost: %v", err)
+			c.closed.Store(true)
+			c.failPending()
+			return
+		}
+
+		switch {
+		case msg.Method != "" && msg.ID != nil:
+			go c.handleServerRequest(msg)
+		case msg.Method != "":
+			c.handleNotification(msg)
 		default:
-			log.Printf("⚠️ Unsupported ID type in response: %T", resp.ID)
-			continue
+			c.deliverResponse(msg)
 		}
+	}
+}
 
-		if respID != id {
-			log.Printf("⚠️ Response ID (%v) does not match request ID (%d), ignored", resp.ID, id)
-			continue
+func (c *GoplsClient) deliverResponse(msg *protocol.JSONRPCMessage) {
+	id, ok := messageID(msg)
+	if !ok {
+		log.Printf("⚠️ Unsupported ID type in response: %T", msg.ID)
+		return
+	}
+
+	c.pendingMutex.Lock()
+	respCh, ok := c.pending[id]
+	delete(c.pending, id)
+	c.pendingMutex.Unlock()
+
+	if !ok {
+		log.Printf("⚠️ Response ID (%v) does not match any pending request, ignored", msg.ID)
+		return
+	}
+
+	respCh <- msg
+}
+
+func (c *GoplsClient) failPending() {
+	c.pendingMutex.Lock()
+	defer c.pendingMutex.Unlock()
+
+	for id, respCh := range c.pending {
+		close(respCh)
+		delete(c.pending, id)
+	}
+}
+
+func messageID(msg *protocol.JSONRPCMessage) (int64, bool) {
+	switch v := msg.ID.(type) {
+	case float64:
+		return int64(v), true
+	case int64:
+		return v, true
+	case json.Number:
+		id, err := v.Int64()
+		if err != nil {
+			log.Printf("⚠️ Invalid ID format in response: %v", msg.ID)
+			return 0, false
 		}
+		return id, true
+	default:
+		return 0, false
+	}
+}
 
-		respBytes, _ := json.MarshalIndent(resp, "", "  ")
-		log.Printf("📥 Response content: %s", string(respBytes))
+func (c *GoplsClient) handleNotification(msg *protocol.JSONRPCMessage) {
+	switch msg.Method {
+	case "textDocument/publishDiagnostics":
+		c.handlePublishDiagnostics(msg)
+	default:
+		log.Printf("⏭️ Ignoring notification: %s", msg.Method)
+	}
+}
 
-		if resp.Error != nil {
-			return nil, fmt.Errorf("LSP error: %s (code: %d)", resp.Error.Message, resp.Error.Code)
+// handleServerRequest answers requests initiated by gopls. gopls blocks on
+// some of them (workspace/configuration in particular), so every request gets
+// a response, even if it is an error.
+func (c *GoplsClient) handleServerRequest(msg *protocol.JSONRPCMessage) {
+	var result any
+	var respErr *protocol.JSONRPCError
+
+	switch msg.Method {
+	case "workspace/configuration":
+		var params struct {
+			Items []struct {
+				ScopeURI string `json:"scopeUri,omitempty"`
+				Section  string `json:"section,omitempty"`
+			} `json:"items"`
+		}
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			respErr = &protocol.JSONRPCError{Code: protocol.CodeInternalError, Message: err.Error()}
+			break
 		}
 
-		return resp, nil
+		items := make([]any, len(params.Items))
+		for i, item := range params.Items {
+			if item.Section == "gopls" {
+				items[i] = c.Settings()
+			}
+		}
+		result = items
+	case "workspace/applyEdit":
+		var params struct {
+			Label string                 `json:"label,omitempty"`
+			Edit  protocol.WorkspaceEdit `json:"edit"`
+		}
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			respErr = &protocol.JSONRPCError{Code: protocol.CodeInternalError, Message: err.Error()}
+			break
+		}
+
+		log.Printf("✏️ Applying workspace edit: %s", params.Label)
+		if err := c.ApplyWorkspaceEdit(params.Edit); err != nil {
+			log.Printf("❌ Failed to apply workspace edit: %v", err)
+			result = map[string]any{"applied": false, "failureReason": err.Error()}
+			break
+		}
+		result = map[string]any{"applied": true}
+	case "client/registerCapability", "client/unregisterCapability", "window/workDoneProgress/create":
+		result = nil
+	default:
+		log.Printf("⚠️ Unsupported server request: %s", msg.Method)
+		respErr = &protocol.JSONRPCError{Code: protocol.CodeMethodNotFound, Message: "method not supported: " + msg.Method}
+	}
+
+	var resp *protocol.JSONRPCMessage
+	if respErr != nil {
+		resp = protocol.NewErrorResponse(msg.ID, respErr.Code, respErr.Message)
+	} else {
+		var err error
+		resp, err = protocol.NewResponse(msg.ID, result)
+		if err != nil {
+			resp = protocol.NewErrorResponse(msg.ID, protocol.CodeInternalError, err.Error())
+		}
 	}
 
-	return nil, fmt.Errorf("no response with matching ID after %v seconds", maxWaitTime.Seconds())
+	if err := c.transport.SendMessage(resp); err != nil {
+		log.Printf("❌ Error answering server request %s: %v", msg.Method, err)
+	}
 }
 
 func (c *GoplsClient) notify(method string, params any) error {
@@ -210,7 +379,8 @@ func (c *GoplsClient) Initialize() error {
 			"name":    "mcp-gopls",
 			"version": "1.0.0",
 		},
-		"rootUri": "file:///",
+		"rootUri":               "file:///",
+		"initializationOptions": c.Settings(),
 		"capabilities": map[string]any{
 			"textDocument": map[string]any{
 				"synchronization": map[string]any{
@@ -250,9 +420,29 @@ func (c *GoplsClient) Initialize() error {
 				"publishDiagnostics": map[string]any{
 					"relatedInformation": true,
 				},
+				"codeAction": map[string]any{
+					"dynamicRegistration": true,
+					"codeActionLiteralSupport": map[string]any{
+						"codeActionKind": map[string]any{
+							"valueSet": []string{"quickfix", "refactor", "refactor.extract", "refactor.inline", "refactor.rewrite", "source", "source.organizeImports"},
+						},
+					},
+					"dataSupport": true,
+					"resolveSupport": map[string]any{
+						"properties": []string{"edit"},
+					},
+				},
+				"callHierarchy": map[string]any{
+					"dynamicRegistration": true,
+				},
 			},
 			"workspace": map[string]any{
-				"applyEdit": true,
+				"applyEdit":     true,
+				"configuration": true,
+				"workspaceEdit": map[string]any{
+					"documentChanges":    true,
+					"resourceOperations": []string{"create", "rename", "delete"},
+				},
 				"didChangeConfiguration": map[string]any{
 					"dynamicRegistration": true,
 				},
@@ -341,6 +531,10 @@ func (c *GoplsClient) Close() error {
 	return nil
 }
 
+func (c *GoplsClient) IsClosed() bool {
+	return c.closed.Load()
+}
+
 func (c *GoplsClient) GoToDefinition(uri string, line, character int) ([]protocol.Location, error) {
 	params := protocol.TextDocumentPositionParams{
 		TextDocument: protocol.TextDocumentIdentifier{
@@ -399,20 +593,35 @@ func (c *GoplsClient) GetDiagnostics(uri string) ([]protocol.Diagnostic, error)
 		return nil, err
 	}
 
-	return []protocol.Diagnostic{}, nil
+	diagnostics, ok := c.waitForDiagnostics(uri, c.documentVersion(uri), diagnosticsTimeout)
+	if !ok {
+		log.Printf("⚠️ No diagnostics published for %s after %v, returning last known diagnostics", uri, diagnosticsTimeout)
+	}
+
+	if diagnostics == nil {
+		diagnostics = []protocol.Diagnostic{}
+	}
+
+	return diagnostics, nil
 }
 
+// DidOpen opens the document in gopls. If text is empty, the content is read
+// from disk. A document that is already open with the same content is left
+// untouched; if its content changed, it is reopened so that gopls analyzes
+// and publishes diagnostics for the new version.
 func (c *GoplsClient) DidOpen(uri, languageID, text string) error {
 	log.Printf("📝 Opening document: %s", uri)
 
 	if text == "" {
-		filePath := strings.TrimPrefix(uri, "file://")
-		if runtime.GOOS == "windows" {
-			filePath = strings.TrimPrefix(filePath, "/")
-			filePath = strings.ReplaceAll(filePath, "/", "\\")
+		c.docsMutex.Lock()
+		_, overlay := c.overlays[uri]
+		c.docsMutex.Unlock()
+		if overlay {
+			log.Printf("✓ Document has an overlay, keeping it: %s", uri)
+			return nil
 		}
 
-		content, err := os.ReadFile(filePath)
+		content, err := os.ReadFile(protocol.URIToPath(uri))
 		if err != nil {
 			log.Printf("⚠️ Unable to read file content: %v", err)
 			text = ""
@@ -422,11 +631,33 @@ func (c *GoplsClient) DidOpen(uri, languageID, text string) error {
 		}
 	}
 
+	c.docsMutex.Lock()
+	current, open := c.openDocs[uri]
+	if open && current == text {
+		c.docsMutex.Unlock()
+		log.Printf("✓ Document already open and unchanged: %s", uri)
+		return nil
+	}
+	c.versions[uri]++
+	version := c.versions[uri]
+	c.openDocs[uri] = text
+	c.docsMutex.Unlock()
+
+	if open {
+		if err := c.notify("textDocument/didClose", map[string]any{
+			"textDocument": map[string]any{
+				"uri": uri,
+			},
+		}); err != nil {
+			return fmt.Errorf("failed to reopen document: %w", err)
+		}
+	}
+
 	params := map[string]any{
 		"textDocument": map[string]any{
 			"uri":        uri,
 			"languageId": languageID,
-			"version":    1,
+			"version":    version,
 			"text":       text,
 		},
 	}
@@ -437,11 +668,15 @@ func (c *GoplsClient) DidOpen(uri, languageID, text string) error {
 		return fmt.Errorf("failed to open document: %w", err)
 	}
 
-	log.Printf("✓ Document opened successfully: %s", uri)
+	log.Printf("✓ Document opened successfully: %s (version %d)", uri, version)
 	return nil
 }
 
 func (c *GoplsClient) DidClose(uri string) error {
+	c.docsMutex.Lock()
+	delete(c.openDocs, uri)
+	c.docsMutex.Unlock()
+
 	params := map[string]any{
 		"textDocument": map[string]any{
 			"uri": uri,
@@ -451,6 +686,137 @@ func (c *GoplsClient) DidClose(uri string) error {
 	return c.notify("textDocument/didClose", params)
 }
 
+// DidChange replaces the content of an open document with a new version,
+// opening it if needed, so that gopls analyzes the new text.
+func (c *GoplsClient) DidChange(uri, text string) error {
+	c.docsMutex.Lock()
+	current, open := c.openDocs[uri]
+	if !open {
+		c.docsMutex.Unlock()
+		return c.DidOpen(uri, languageIDFor(uri), text)
+	}
+	if current == text {
+		c.docsMutex.Unlock()
+		return nil
+	}
+	c.versions[uri]++
+	version := c.versions[uri]
+	c.openDocs[uri] = text
+	c.docsMutex.Unlock()
+
+	log.Printf("📝 Changing document: %s (version %d)", uri, version)
+
+	params := map[string]any{
+		"textDocument": map[string]any{
+			"uri":     uri,
+			"version": version,
+		},
+		"contentChanges": []map[string]any{
+			{"text": text},
+		},
+	}
+
+	if err := c.notify("textDocument/didChange", params); err != nil {
+		return fmt.Errorf("failed to change document: %w", err)
+	}
+	return nil
+}
+
+// overlayWaitTimeout is how long SetOverlay waits for the current overlay of
+// a document to be cleared
+const overlayWaitTimeout = time.Minute
+
+// SetOverlay sends content for a document that is not on disk. Until
+// ClearOverlay, gopls analyzes that content and requests on the document do
+// not reload it from disk. Overlays of the same document are exclusive:
+// SetOverlay waits for the current one to be cleared, up to
+// overlayWaitTimeout. The holder of an overlay updates it with DidChange.
+func (c *GoplsClient) SetOverlay(uri, text string) error {
+	deadline := time.After(overlayWaitTimeout)
+	var cleared chan struct{}
+	for {
+		c.docsMutex.Lock()
+		current, busy := c.overlays[uri]
+		if !busy {
+			cleared = make(chan struct{})
+			c.overlays[uri] = cleared
+			c.docsMutex.Unlock()
+			break
+		}
+		c.docsMutex.Unlock()
+
+		log.Printf("⏳ Waiting for the overlay of %s to be cleared", uri)
+		select {
+		case <-current:
+		case <-deadline:
+			return fmt.Errorf("timed out after %v waiting for the overlay of %s to be cleared", overlayWaitTimeout, uri)
+		}
+	}
+
+	log.Printf("📝 Setting overlay for %s (%d bytes)", uri, len(text))
+	if err := c.DidChange(uri, text); err != nil {
+		// Without an overlay to clear, release the document right away
+		c.docsMutex.Lock()
+		if c.overlays[uri] == cleared {
+			delete(c.overlays, uri)
+			close(cleared)
+		}
+		c.docsMutex.Unlock()
+		return err
+	}
+	return nil
+}
+
+// Overlay returns the content of a document set by SetOverlay, including the
+// workspace edits applied to it since
+func (c *GoplsClient) Overlay(uri string) (string, bool) {
+	c.docsMutex.Lock()
+	defer c.docsMutex.Unlock()
+	if _, ok := c.overlays[uri]; !ok {
+		return "", false
+	}
+	return c.openDocs[uri], true
+}
+
+// ClearOverlay reverts a document to its content on disk, closing it when
+// the file does not exist.
+func (c *GoplsClient) ClearOverlay(uri string) error {
+	c.docsMutex.Lock()
+	cleared, overlay := c.overlays[uri]
+	c.docsMutex.Unlock()
+
+	if !overlay {
+		return nil
+	}
+
+	// The next overlay of the document is set after the revert
+	defer func() {
+		c.docsMutex.Lock()
+		defer c.docsMutex.Unlock()
+		if c.overlays[uri] == cleared {
+			delete(c.overlays, uri)
+			close(cleared)
+		}
+	}()
+
+	log.Printf("📝 Clearing overlay for %s", uri)
+	content, err := os.ReadFile(protocol.URIToPath(uri))
+	if os.IsNotExist(err) {
+		return c.DidClose(uri)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", uri, err)
+	}
+	return c.DidChange(uri, string(content))
+}
+
+// documentVersion returns the version of the document last sent to gopls
+func (c *GoplsClient) documentVersion(uri string) int {
+	c.docsMutex.Lock()
+	defer c.docsMutex.Unlock()
+	return c.versions[uri]
+}
+
 func (c *GoplsClient) GetHover(uri string, line, character int) (string, error) {
 	log.Printf("🔍 Requesting hover information for %s position L%d:C%d", uri, line, character)
 
@@ -611,6 +977,40 @@ func (c *GoplsClient) GetDocumentSymbols(uri string) ([]protocol.DocumentSymbol,
 }
 
 func (c *GoplsClient) GetWorkspaceSymbols(query string) ([]protocol.SymbolInformation, error) {
+	c.symbolMutex.RLock()
+	defer c.symbolMutex.RUnlock()
+	return c.workspaceSymbols(query)
+}
+
+// GetWorkspaceSymbolsWithSettings searches workspace symbols with gopls
+// settings such as "symbolMatcher" and "symbolScope" applied for this query
+// only. The previous settings are restored afterwards, and other symbol
+// queries wait meanwhile.
+func (c *GoplsClient) GetWorkspaceSymbolsWithSettings(query string, settings map[string]any) ([]protocol.SymbolInformation, error) {
+	if len(settings) == 0 {
+		return c.GetWorkspaceSymbols(query)
+	}
+
+	c.symbolMutex.Lock()
+	defer c.symbolMutex.Unlock()
+
+	current := c.Settings()
+	previous := make(map[string]any, len(settings))
+	for k := range settings {
+		previous[k] = current[k]
+	}
+
+	if err := c.UpdateSettings(settings); err != nil {
+		return nil, err
+	}
+	symbols, err := c.workspaceSymbols(query)
+	if restoreErr := c.UpdateSettings(previous); restoreErr != nil {
+		return nil, errors.Join(err, restoreErr)
+	}
+	return symbols, err
+}
+
+func (c *GoplsClient) workspaceSymbols(query string) ([]protocol.SymbolInformation, error) {
 	log.Printf("🔍 Searching workspace symbols with query: %s", query)
 
 	params := map[string]any{
@@ -674,3 +1074,45 @@ func (c *GoplsClient) GetImplementations(uri string, line, character int) ([]pro
 
 	return locations, nil
 }
+
+// Settings returns a copy of the gopls settings served on
+// workspace/configuration.
+func (c *GoplsClient) Settings() map[string]any {
+	c.settingsMutex.RLock()
+	defer c.settingsMutex.RUnlock()
+
+	settings := make(map[string]any, len(c.settings))
+	for k, v := range c.settings {
+		settings[k] = v
+	}
+	return settings
+}
+
+// UpdateSettings merges the given gopls settings into the current ones and
+// notifies gopls, which then pulls them through workspace/configuration. A nil
+// value removes the setting.
+func (c *GoplsClient) UpdateSettings(settings map[string]any) error {
+	c.settingsMutex.Lock()
+	for k, v := range settings {
+		if v == nil {
+			delete(c.settings, k)
+			continue
+		}
+		c.settings[k] = v
+	}
+	c.settingsMutex.Unlock()
+
+	log.Printf("⚙️ Updating gopls settings: %v", settings)
+
+	params := map[string]any{
+		"settings": map[string]any{
+			"gopls": c.Settings(),
+		},
+	}
+
+	if err := c.notify("workspace/didChangeConfiguration", params); err != nil {
+		return fmt.Errorf("failed to send configuration change: %w", err)
+	}
+
+	return nil
+}
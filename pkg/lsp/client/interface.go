@@ -1,6 +1,9 @@
 package client
 
 import (
+	"encoding/json"
+	"time"
+
 	"github.com/solatis/mcp-gopls/pkg/lsp/protocol"
 )
 
@@ -10,6 +13,7 @@ type LSPClient interface {
 	Initialize() error
 	Shutdown() error
 	Close() error
+	IsClosed() bool
 
 	// Méthodes de navigation de code
 	GoToDefinition(uri string, line, character int) ([]protocol.Location, error)
@@ -17,10 +21,17 @@ type LSPClient interface {
 
 	// Méthodes de diagnostic
 	GetDiagnostics(uri string) ([]protocol.Diagnostic, error)
+	WorkspaceDiagnostics() map[string][]protocol.Diagnostic
+	WaitForDiagnosticsIdle(quiet, timeout time.Duration)
+	DiagnosticsUpdated() <-chan struct{}
 
 	// Méthodes de document
 	DidOpen(uri, languageID, text string) error
 	DidClose(uri string) error
+	DidChange(uri, text string) error
+	SetOverlay(uri, text string) error
+	ClearOverlay(uri string) error
+	Overlay(uri string) (string, bool)
 
 	// Support avancé
 	GetHover(uri string, line, character int) (string, error)
@@ -29,5 +40,21 @@ type LSPClient interface {
 	// Symbol navigation
 	GetDocumentSymbols(uri string) ([]protocol.DocumentSymbol, error)
 	GetWorkspaceSymbols(query string) ([]protocol.SymbolInformation, error)
+	GetWorkspaceSymbolsWithSettings(query string, settings map[string]any) ([]protocol.SymbolInformation, error)
 	GetImplementations(uri string, line, character int) ([]protocol.Location, error)
+
+	// Call hierarchy
+	PrepareCallHierarchy(uri string, line, character int) ([]protocol.CallHierarchyItem, error)
+	IncomingCalls(item protocol.CallHierarchyItem) ([]protocol.CallHierarchyIncomingCall, error)
+
+	// Commands and edits
+	ExecuteCommand(command string, arguments ...any) (json.RawMessage, error)
+	ApplyWorkspaceEdit(edit protocol.WorkspaceEdit) error
+	CodeActions(uri string, rng protocol.Range, only ...string) ([]protocol.CodeAction, error)
+	ApplyCodeAction(action protocol.CodeAction) error
+	DidChangeFiles(uris ...string) error
+
+	// gopls settings
+	Settings() map[string]any
+	UpdateSettings(settings map[string]any) error
 }
@@ -0,0 +1,171 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/solatis/mcp-gopls/pkg/lsp/protocol"
+)
+
+// File change types of workspace/didChangeWatchedFiles
+const (
+	fileCreated = 1
+	fileChanged = 2
+	fileDeleted = 3
+)
+
+func (c *GoplsClient) ExecuteCommand(command string, arguments ...any) (json.RawMessage, error) {
+	log.Printf("⚙️ Executing command %s", command)
+
+	if arguments == nil {
+		arguments = []any{}
+	}
+
+	params := map[string]any{
+		"command":   command,
+		"arguments": arguments,
+	}
+
+	resp, err := c.call("workspace/executeCommand", params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute command %s: %w", command, err)
+	}
+
+	return resp.Result, nil
+}
+
+// ApplyWorkspaceEdit writes a workspace edit to disk and tells gopls about the
+// changed files: open documents are reopened with their new content, other
+// files are reported as changed on disk. Edits to documents with an overlay
+// update the overlay and leave the disk untouched.
+func (c *GoplsClient) ApplyWorkspaceEdit(edit protocol.WorkspaceEdit) error {
+	var changes []map[string]any
+
+	applyEdits := func(uri string, edits []protocol.TextEdit) error {
+		if overlay, ok := c.Overlay(uri); ok {
+			updated, err := protocol.ApplyTextEdits(overlay, edits)
+			if err != nil {
+				return fmt.Errorf("failed to apply edits to %s: %w", uri, err)
+			}
+			log.Printf("✓ Applied %d edits to the overlay of %s", len(edits), uri)
+			return c.DidChange(uri, updated)
+		}
+
+		path := protocol.URIToPath(uri)
+		content, err := os.ReadFile(path)
+		if err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to read %s: %w", path, err)
+		}
+
+		updated, err := protocol.ApplyTextEdits(string(content), edits)
+		if err != nil {
+			return fmt.Errorf("failed to apply edits to %s: %w", path, err)
+		}
+
+		if err := os.WriteFile(path, []byte(updated), 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", path, err)
+		}
+		log.Printf("✓ Applied %d edits to %s", len(edits), path)
+
+		if c.isOpen(uri) {
+			return c.DidOpen(uri, languageIDFor(uri), updated)
+		}
+		changes = append(changes, map[string]any{"uri": uri, "type": fileChanged})
+		return nil
+	}
+
+	for uri, edits := range edit.Changes {
+		if err := applyEdits(uri, edits); err != nil {
+			return err
+		}
+	}
+
+	for _, change := range edit.DocumentChanges {
+		switch change.Kind {
+		case "":
+			if err := applyEdits(change.TextDocument.URI, change.Edits); err != nil {
+				return err
+			}
+		case "create":
+			path := protocol.URIToPath(change.URI)
+			if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+				return fmt.Errorf("failed to create directory for %s: %w", path, err)
+			}
+			if err := os.WriteFile(path, nil, 0644); err != nil {
+				return fmt.Errorf("failed to create %s: %w", path, err)
+			}
+			changes = append(changes, map[string]any{"uri": change.URI, "type": fileCreated})
+		case "rename":
+			if err := os.Rename(protocol.URIToPath(change.OldURI), protocol.URIToPath(change.NewURI)); err != nil {
+				return fmt.Errorf("failed to rename %s: %w", change.OldURI, err)
+			}
+			changes = append(changes,
+				map[string]any{"uri": change.OldURI, "type": fileDeleted},
+				map[string]any{"uri": change.NewURI, "type": fileCreated})
+		case "delete":
+			if err := os.Remove(protocol.URIToPath(change.URI)); err != nil {
+				return fmt.Errorf("failed to delete %s: %w", change.URI, err)
+			}
+			changes = append(changes, map[string]any{"uri": change.URI, "type": fileDeleted})
+		default:
+			return fmt.Errorf("unsupported document change: %s", change.Kind)
+		}
+	}
+
+	if len(changes) == 0 {
+		return nil
+	}
+
+	return c.notify("workspace/didChangeWatchedFiles", map[string]any{
+		"changes": changes,
+	})
+}
+
+// DidChangeFiles tells gopls that files were changed on disk by another
+// program: open documents are reopened with their new content, other files
+// are reported as changed on disk.
+func (c *GoplsClient) DidChangeFiles(uris ...string) error {
+	var changes []map[string]any
+	for _, uri := range uris {
+		if c.isOpen(uri) {
+			if err := c.DidOpen(uri, languageIDFor(uri), ""); err != nil {
+				return err
+			}
+			continue
+		}
+		changes = append(changes, map[string]any{"uri": uri, "type": fileChanged})
+	}
+
+	if len(changes) == 0 {
+		return nil
+	}
+
+	return c.notify("workspace/didChangeWatchedFiles", map[string]any{
+		"changes": changes,
+	})
+}
+
+func (c *GoplsClient) isOpen(uri string) bool {
+	c.docsMutex.Lock()
+	defer c.docsMutex.Unlock()
+	_, ok := c.openDocs[uri]
+	return ok
+}
+
+// languageIDFor returns the LSP language identifier gopls expects for a file
+func languageIDFor(uri string) string {
+	switch {
+	case strings.HasSuffix(uri, "/go.mod"):
+		return "go.mod"
+	case strings.HasSuffix(uri, "/go.work"):
+		return "go.work"
+	case strings.HasSuffix(uri, ".tmpl"):
+		return "tmpl"
+	default:
+		return "go"
+	}
+}
@@ -0,0 +1,42 @@
+package protocol
+
+import "encoding/json"
+
+// Command is a command the server can execute through workspace/executeCommand
+type Command struct {
+	Title     string            `json:"title"`
+	Command   string            `json:"command"`
+	Arguments []json.RawMessage `json:"arguments,omitempty"`
+}
+
+// CodeActionDisabled explains why a code action is not applicable
+type CodeActionDisabled struct {
+	Reason string `json:"reason"`
+}
+
+// CodeAction is a change, such as a refactoring or quick fix, offered for a
+// range of a document. Its effect is the edit, the command, or both; when the
+// server resolves actions lazily, neither is set until codeAction/resolve.
+type CodeAction struct {
+	Title       string              `json:"title"`
+	Kind        string              `json:"kind,omitempty"`
+	Diagnostics []Diagnostic        `json:"diagnostics,omitempty"`
+	IsPreferred bool                `json:"isPreferred,omitempty"`
+	Disabled    *CodeActionDisabled `json:"disabled,omitempty"`
+	Edit        *WorkspaceEdit      `json:"edit,omitempty"`
+	Command     *Command            `json:"command,omitempty"`
+	Data        json.RawMessage     `json:"data,omitempty"`
+}
+
+// CodeActionContext carries the diagnostics and the kinds of actions requested
+type CodeActionContext struct {
+	Diagnostics []Diagnostic `json:"diagnostics"`
+	Only        []string     `json:"only,omitempty"`
+}
+
+// CodeActionParams are the parameters of textDocument/codeAction
+type CodeActionParams struct {
+	TextDocument TextDocumentIdentifier `json:"textDocument"`
+	Range        Range                  `json:"range"`
+	Context      CodeActionContext      `json:"context"`
+}
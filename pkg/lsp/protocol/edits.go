@@ -0,0 +1,141 @@
+package protocol
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// TextEdit is a textual edit applicable to a text document
+type TextEdit struct {
+	Range   Range  `json:"range"`
+	NewText string `json:"newText"`
+}
+
+// VersionedTextDocumentIdentifier identifies a specific version of a text document
+type VersionedTextDocumentIdentifier struct {
+	URI     string `json:"uri"`
+	Version *int   `json:"version"`
+}
+
+// TextDocumentEdit describes the edits on a single text document
+type TextDocumentEdit struct {
+	TextDocument VersionedTextDocumentIdentifier `json:"textDocument"`
+	Edits        []TextEdit                      `json:"edits"`
+}
+
+// DocumentChange is an entry of WorkspaceEdit.documentChanges: either a text
+// document edit or a create, rename or delete file operation (Kind set)
+type DocumentChange struct {
+	TextDocumentEdit
+	Kind   string `json:"kind,omitempty"`
+	URI    string `json:"uri,omitempty"`
+	OldURI string `json:"oldUri,omitempty"`
+	NewURI string `json:"newUri,omitempty"`
+}
+
+// WorkspaceEdit represents changes to many resources managed in the workspace
+type WorkspaceEdit struct {
+	Changes         map[string][]TextEdit `json:"changes,omitempty"`
+	DocumentChanges []DocumentChange      `json:"documentChanges,omitempty"`
+}
+
+// PositionToOffset converts an LSP position, whose character is counted in
+// UTF-16 code units, to a byte offset in content. Positions past the end of
+// a line are clamped to the end of the line.
+func PositionToOffset(content string, pos Position) (int, error) {
+	if pos.Line < 0 || pos.Character < 0 {
+		return 0, fmt.Errorf("invalid position %d:%d", pos.Line, pos.Character)
+	}
+
+	offset := 0
+	for line := 0; line < pos.Line; line++ {
+		i := strings.IndexByte(content[offset:], '\n')
+		if i < 0 {
+			if line == pos.Line-1 {
+				// Position on the line after a missing final newline
+				return len(content), nil
+			}
+			return 0, fmt.Errorf("line %d is out of range", pos.Line)
+		}
+		offset += i + 1
+	}
+
+	units := 0
+	for offset < len(content) && units < pos.Character {
+		r, size := utf8.DecodeRuneInString(content[offset:])
+		if r == '\n' || (r == '\r' && strings.HasPrefix(content[offset+1:], "\n")) {
+			break
+		}
+		if r >= 0x10000 {
+			units += 2
+		} else {
+			units++
+		}
+		offset += size
+	}
+
+	return offset, nil
+}
+
+// OffsetToPosition converts a byte offset in content to an LSP position
+func OffsetToPosition(content string, offset int) Position {
+	offset = min(max(offset, 0), len(content))
+
+	lineStart := strings.LastIndexByte(content[:offset], '\n') + 1
+	line := strings.Count(content[:lineStart], "\n")
+
+	character := 0
+	for _, r := range content[lineStart:offset] {
+		if r >= 0x10000 {
+			character += 2
+		} else {
+			character++
+		}
+	}
+
+	return Position{Line: line, Character: character}
+}
+
+// ApplyTextEdits applies non-overlapping edits to content
+func ApplyTextEdits(content string, edits []TextEdit) (string, error) {
+	type span struct {
+		start, end int
+		text       string
+	}
+
+	spans := make([]span, 0, len(edits))
+	for _, edit := range edits {
+		start, err := PositionToOffset(content, edit.Range.Start)
+		if err != nil {
+			return "", err
+		}
+		end, err := PositionToOffset(content, edit.Range.End)
+		if err != nil {
+			return "", err
+		}
+		if end < start {
+			return "", fmt.Errorf("invalid edit range %d:%d-%d:%d", edit.Range.Start.Line, edit.Range.Start.Character, edit.Range.End.Line, edit.Range.End.Character)
+		}
+		spans = append(spans, span{start, end, edit.NewText})
+	}
+
+	sort.SliceStable(spans, func(i, j int) bool {
+		return spans[i].start < spans[j].start
+	})
+
+	var b strings.Builder
+	last := 0
+	for _, s := range spans {
+		if s.start < last {
+			return "", fmt.Errorf("overlapping edits at offset %d", s.start)
+		}
+		b.WriteString(content[last:s.start])
+		b.WriteString(s.text)
+		last = s.end
+	}
+	b.WriteString(content[last:])
+
+	return b.String(), nil
+}
@@ -0,0 +1,173 @@
+package protocol
+
+import "testing"
+
+func pos(line, character int) Position {
+	return Position{Line: line, Character: character}
+}
+
+func edit(startLine, startChar, endLine, endChar int, text string) TextEdit {
+	return TextEdit{
+		Range:   Range{Start: pos(startLine, startChar), End: pos(endLine, endChar)},
+		NewText: text,
+	}
+}
+
+func TestPositionToOffset(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		pos     Position
+		want    int
+		wantErr bool
+	}{
+		{"start", "abc\ndef\n", pos(0, 0), 0, false},
+		{"second line", "abc\ndef\n", pos(1, 2), 6, false},
+		{"past end of line", "abc\ndef\n", pos(0, 10), 3, false},
+		{"line after final newline", "abc\n", pos(1, 0), 4, false},
+		{"line after missing final newline", "abc", pos(1, 0), 3, false},
+		{"end of last line without newline", "abc\ndef", pos(1, 3), 7, false},
+		{"line out of range", "abc\n", pos(3, 0), 0, true},
+		{"negative position", "abc", pos(-1, 0), 0, true},
+		// "é" is 2 bytes and 1 UTF-16 unit
+		{"multi-byte", "héllo", pos(0, 2), 3, false},
+		// "😀" is 4 bytes and 2 UTF-16 units
+		{"astral", "a😀b", pos(0, 3), 5, false},
+		{"crlf second line", "ab\r\ncd\r\n", pos(1, 1), 5, false},
+		{"crlf past end of line", "ab\r\ncd\r\n", pos(0, 10), 2, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := PositionToOffset(tt.content, tt.pos)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("PositionToOffset error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && got != tt.want {
+				t.Errorf("PositionToOffset = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOffsetToPosition(t *testing.T) {
+	content := "a😀b\nhé\n"
+	tests := []struct {
+		offset int
+		want   Position
+	}{
+		{0, pos(0, 0)},
+		{5, pos(0, 3)},
+		{7, pos(1, 0)},
+		{10, pos(1, 2)},
+		{100, pos(2, 0)},
+	}
+
+	for _, tt := range tests {
+		if got := OffsetToPosition(content, tt.offset); got != tt.want {
+			t.Errorf("OffsetToPosition(%d) = %v, want %v", tt.offset, got, tt.want)
+		}
+		if offset, err := PositionToOffset(content, tt.want); err != nil || offset != min(tt.offset, len(content)) {
+			t.Errorf("PositionToOffset(%v) = %d, %v, want %d", tt.want, offset, err, tt.offset)
+		}
+	}
+}
+
+func TestApplyTextEdits(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		edits   []TextEdit
+		want    string
+		wantErr bool
+	}{
+		{
+			name:    "no edits",
+			content: "abc\n",
+			want:    "abc\n",
+		},
+		{
+			name:    "insert, replace and delete",
+			content: "one\ntwo\nthree\n",
+			edits: []TextEdit{
+				edit(0, 0, 0, 0, "zero\n"),
+				edit(1, 0, 1, 3, "TWO"),
+				edit(2, 0, 3, 0, ""),
+			},
+			want: "zero\none\nTWO\n",
+		},
+		{
+			name:    "unsorted edits",
+			content: "abcdef",
+			edits: []TextEdit{
+				edit(0, 4, 0, 5, "E"),
+				edit(0, 0, 0, 1, "A"),
+			},
+			want: "AbcdEf",
+		},
+		{
+			name:    "inserts at the same position keep their order",
+			content: "x",
+			edits: []TextEdit{
+				edit(0, 0, 0, 0, "1"),
+				edit(0, 0, 0, 0, "2"),
+			},
+			want: "12x",
+		},
+		{
+			name:    "overlapping edits",
+			content: "abcdef",
+			edits: []TextEdit{
+				edit(0, 0, 0, 3, "X"),
+				edit(0, 2, 0, 4, "Y"),
+			},
+			wantErr: true,
+		},
+		{
+			name:    "reversed range",
+			content: "abcdef",
+			edits:   []TextEdit{edit(0, 3, 0, 1, "X")},
+			wantErr: true,
+		},
+		{
+			name:    "multi-byte and astral characters",
+			content: "héllo 😀 wörld\n",
+			edits: []TextEdit{
+				edit(0, 1, 0, 2, "e"),
+				edit(0, 6, 0, 8, ":)"),
+				edit(0, 10, 0, 11, "o"),
+			},
+			want: "hello :) world\n",
+		},
+		{
+			name:    "crlf",
+			content: "a := 1\r\nb := 2\r\n",
+			edits:   []TextEdit{edit(1, 5, 1, 6, "3")},
+			want:    "a := 1\r\nb := 3\r\n",
+		},
+		{
+			name:    "append to a file without trailing newline",
+			content: "package p",
+			edits:   []TextEdit{edit(0, 9, 0, 9, "\n\nvar x int\n")},
+			want:    "package p\n\nvar x int\n",
+		},
+		{
+			name:    "edit on the line after a missing final newline",
+			content: "package p",
+			edits:   []TextEdit{edit(1, 0, 1, 0, "\n")},
+			want:    "package p\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ApplyTextEdits(tt.content, tt.edits)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ApplyTextEdits error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && got != tt.want {
+				t.Errorf("ApplyTextEdits = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
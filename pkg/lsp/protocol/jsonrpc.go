@@ -14,6 +14,12 @@ type JSONRPCMessage struct {
 	Error   *JSONRPCError   `json:"error,omitempty"`
 }
 
+// Standard JSON-RPC error codes
+const (
+	CodeMethodNotFound = -32601
+	CodeInternalError  = -32603
+)
+
 type JSONRPCError struct {
 	Code    int             `json:"code"`
 	Message string          `json:"message"`
@@ -91,6 +97,30 @@ func NewNotification(method string, params any) (*JSONRPCMessage, error) {
 	}, nil
 }
 
+func NewResponse(id any, result any) (*JSONRPCMessage, error) {
+	resultRaw, err := json.Marshal(result)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal result: %w", err)
+	}
+
+	return &JSONRPCMessage{
+		JSONRPC: "2.0",
+		ID:      id,
+		Result:  resultRaw,
+	}, nil
+}
+
+func NewErrorResponse(id any, code int, message string) *JSONRPCMessage {
+	return &JSONRPCMessage{
+		JSONRPC: "2.0",
+		ID:      id,
+		Error: &JSONRPCError{
+			Code:    code,
+			Message: message,
+		},
+	}
+}
+
 func (msg *JSONRPCMessage) ParseResult(target any) error {
 	if msg.Error != nil {
 		return msg.Error
@@ -4,13 +4,13 @@ import (
 	"bufio"
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"strconv"
 	"strings"
 	"sync"
-	"time"
 )
 
 type Transport struct {
@@ -87,7 +87,9 @@ func (t *Transport) SendMessage(msg *JSONRPCMessage) error {
 	return nil
 }
 
-func (t *Transport) ReceiveMessage() (*JSONRPCMessage, error) {
+// ReadMessage blocks until the next message of any kind (response,
+// notification or server request) has been read from the server.
+func (t *Transport) ReadMessage() (*JSONRPCMessage, error) {
 	t.readMutex.Lock()
 	defer t.readMutex.Unlock()
 
@@ -95,82 +97,43 @@ func (t *Transport) ReceiveMessage() (*JSONRPCMessage, error) {
 		return nil, fmt.Errorf("transport closed")
 	}
 
-	timeoutDuration := 30 * time.Second
-	resultCh := make(chan struct {
-		msg *JSONRPCMessage
-		err error
-	}, 1)
-
-	go func() {
-		for {
-			contentLength, err := t.readHeader()
-			if err != nil {
-				if err == io.EOF || strings.Contains(err.Error(), "pipe") || strings.Contains(err.Error(), "connection") {
-					t.Close()
-					resultCh <- struct {
-						msg *JSONRPCMessage
-						err error
-					}{nil, fmt.Errorf("error reading header (transport closed): %w", err)}
-					return
-				}
-				resultCh <- struct {
-					msg *JSONRPCMessage
-					err error
-				}{nil, fmt.Errorf("error reading header: %w", err)}
-				return
-			}
-
-			content, err := t.readContent(contentLength)
-			if err != nil {
-				if err == io.EOF || strings.Contains(err.Error(), "pipe") || strings.Contains(err.Error(), "connection") {
-					t.Close()
-					resultCh <- struct {
-						msg *JSONRPCMessage
-						err error
-					}{nil, fmt.Errorf("error reading content (transport closed): %w", err)}
-					return
-				}
-				resultCh <- struct {
-					msg *JSONRPCMessage
-					err error
-				}{nil, fmt.Errorf("error reading content: %w", err)}
-				return
-			}
+	contentLength, err := t.readHeader()
+	if err != nil {
+		if isConnectionError(err) {
+			t.Close()
+			return nil, fmt.Errorf("error reading header (transport closed): %w", err)
+		}
+		return nil, fmt.Errorf("error reading header: %w", err)
+	}
 
-			var msg JSONRPCMessage
-			if err := json.Unmarshal(content, &msg); err != nil {
-				resultCh <- struct {
-					msg *JSONRPCMessage
-					err error
-				}{nil, fmt.Errorf("error deserializing JSON-RPC message: %w", err)}
-				return
-			}
+	content, err := t.readContent(contentLength)
+	if err != nil {
+		if isConnectionError(err) {
+			t.Close()
+			return nil, fmt.Errorf("error reading content (transport closed): %w", err)
+		}
+		return nil, fmt.Errorf("error reading content: %w", err)
+	}
 
-			messageType := "response"
-			if msg.ID == nil {
-				messageType = "notification"
-			}
-			log.Printf("📥 %s message received: %s", messageType, string(content))
+	var msg JSONRPCMessage
+	if err := json.Unmarshal(content, &msg); err != nil {
+		return nil, fmt.Errorf("error deserializing JSON-RPC message: %w", err)
+	}
 
-			if msg.ID == nil {
-				log.Printf("⏭️ Ignoring notification: %s", msg.Method)
-				continue
-			}
+	messageType := "response"
+	if msg.ID == nil {
+		messageType = "notification"
+	} else if msg.Method != "" {
+		messageType = "request"
+	}
+	log.Printf("📥 %s message received: %s", messageType, string(content))
 
-			resultCh <- struct {
-				msg *JSONRPCMessage
-				err error
-			}{&msg, nil}
-			return
-		}
-	}()
+	return &msg, nil
+}
 
-	select {
-	case result := <-resultCh:
-		return result.msg, result.err
-	case <-time.After(timeoutDuration):
-		return nil, fmt.Errorf("timeout: no response received after %v seconds", timeoutDuration.Seconds())
-	}
+func isConnectionError(err error) bool {
+	return errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) ||
+		strings.Contains(err.Error(), "pipe") || strings.Contains(err.Error(), "connection")
 }
 
 func (t *Transport) readHeader() (int, error) {
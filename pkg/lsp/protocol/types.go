@@ -1,5 +1,10 @@
 package protocol
 
+import (
+	"fmt"
+	"strings"
+)
+
 // Position représente une position dans un document texte
 type Position struct {
 	Line      int `json:"line"`
@@ -59,6 +64,32 @@ const (
 	SeverityHint    DiagnosticSeverity = 4
 )
 
+var severityNames = map[DiagnosticSeverity]string{
+	SeverityError:   "error",
+	SeverityWarning: "warning",
+	SeverityInfo:    "info",
+	SeverityHint:    "hint",
+}
+
+// String returns the lower-case name of the severity, e.g. "warning"
+func (s DiagnosticSeverity) String() string {
+	if name, ok := severityNames[s]; ok {
+		return name
+	}
+	return fmt.Sprintf("DiagnosticSeverity(%d)", int(s))
+}
+
+// ParseDiagnosticSeverity returns the severity for a name such as "error",
+// ignoring case
+func ParseDiagnosticSeverity(name string) (DiagnosticSeverity, bool) {
+	for severity, severityName := range severityNames {
+		if strings.EqualFold(severityName, name) {
+			return severity, true
+		}
+	}
+	return 0, false
+}
+
 // SymbolKind represents the kind of a symbol
 type SymbolKind int
 
@@ -91,6 +122,53 @@ const (
 	SKTypeParameter SymbolKind = 26
 )
 
+var symbolKindNames = map[SymbolKind]string{
+	SKFile:          "file",
+	SKModule:        "module",
+	SKNamespace:     "namespace",
+	SKPackage:       "package",
+	SKClass:         "class",
+	SKMethod:        "method",
+	SKProperty:      "property",
+	SKField:         "field",
+	SKConstructor:   "constructor",
+	SKEnum:          "enum",
+	SKInterface:     "interface",
+	SKFunction:      "function",
+	SKVariable:      "variable",
+	SKConstant:      "constant",
+	SKString:        "string",
+	SKNumber:        "number",
+	SKBoolean:       "boolean",
+	SKArray:         "array",
+	SKObject:        "object",
+	SKKey:           "key",
+	SKNull:          "null",
+	SKEnumMember:    "enumMember",
+	SKStruct:        "struct",
+	SKEvent:         "event",
+	SKOperator:      "operator",
+	SKTypeParameter: "typeParameter",
+}
+
+// String returns the LSP name of the symbol kind, e.g. "function"
+func (k SymbolKind) String() string {
+	if name, ok := symbolKindNames[k]; ok {
+		return name
+	}
+	return fmt.Sprintf("SymbolKind(%d)", int(k))
+}
+
+// ParseSymbolKind returns the symbol kind for an LSP kind name, ignoring case
+func ParseSymbolKind(name string) (SymbolKind, bool) {
+	for kind, kindName := range symbolKindNames {
+		if strings.EqualFold(kindName, name) {
+			return kind, true
+		}
+	}
+	return 0, false
+}
+
 // DocumentSymbol represents programming constructs like variables, classes, interfaces etc.
 type DocumentSymbol struct {
 	Name           string           `json:"name"`
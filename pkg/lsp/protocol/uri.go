@@ -0,0 +1,27 @@
+package protocol
+
+import (
+	"net/url"
+	"path/filepath"
+	"strings"
+)
+
+// URIToPath converts a file:// URI back to a file system path. Other strings
+// are returned unchanged.
+func URIToPath(uri string) string {
+	if !strings.HasPrefix(uri, "file://") {
+		return uri
+	}
+
+	path := strings.TrimPrefix(uri, "file://")
+	if unescaped, err := url.PathUnescape(path); err == nil {
+		path = unescaped
+	}
+
+	if filepath.Separator == '\\' {
+		path = strings.TrimPrefix(path, "/")
+		path = strings.ReplaceAll(path, "/", "\\")
+	}
+
+	return path
+}
@@ -0,0 +1,57 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Config holds the options used to create the service
+type Config struct {
+	// GoplsSettings are passed to gopls as initializationOptions and served
+	// on workspace/configuration, e.g. buildFlags, env, directoryFilters,
+	// analyses, staticcheck or hints.
+	GoplsSettings map[string]any
+}
+
+// LoadGoplsSettings reads gopls settings from a JSON file. The file contains
+// either the settings object itself or an object with a "gopls" key, as in
+// editor configurations.
+func LoadGoplsSettings(path string) (map[string]any, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read gopls settings file: %w", err)
+	}
+
+	var settings map[string]any
+	if err := json.Unmarshal(data, &settings); err != nil {
+		return nil, fmt.Errorf("failed to parse gopls settings file %s: %w", path, err)
+	}
+
+	if nested, ok := settings["gopls"].(map[string]any); ok && len(settings) == 1 {
+		settings = nested
+	}
+	if settings == nil {
+		settings = map[string]any{}
+	}
+
+	return settings, nil
+}
+
+// ParseGoplsSetting parses a "key=value" setting. The value is decoded as
+// JSON when possible, so that booleans, lists and objects can be given on the
+// command line, and is used as a plain string otherwise.
+func ParseGoplsSetting(setting string) (string, any, error) {
+	key, raw, ok := strings.Cut(setting, "=")
+	if !ok || key == "" {
+		return "", nil, fmt.Errorf("invalid gopls setting %q, expected key=value", setting)
+	}
+
+	var value any
+	if err := json.Unmarshal([]byte(raw), &value); err != nil {
+		value = raw
+	}
+
+	return key, value, nil
+}
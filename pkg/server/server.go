@@ -1,6 +1,7 @@
 package server
 
 import (
+	"encoding/json"
 	"fmt"
 	"log"
 	"os"
@@ -15,10 +16,24 @@ import (
 )
 
 type Service struct {
-	server      *server.MCPServer
-	lspClient   client.LSPClient
-	logFile     *os.File
-	clientMutex sync.Mutex
+	server        *server.MCPServer
+	lspClient     client.LSPClient
+	logFile       *os.File
+	clientMutex   sync.Mutex
+	goplsSettings map[string]any
+
+	// configClients are additional gopls instances, one per build
+	// configuration (GOOS/GOARCH/tags), keyed by the JSON of their settings
+	// since configurations with the same label may differ in their settings
+	configClients map[string]*configClient
+	configMutex   sync.Mutex
+}
+
+// configClient is a gopls instance dedicated to a build configuration, along
+// with the label of the configuration
+type configClient struct {
+	client client.LSPClient
+	label  string
 }
 
 // setupLogger initializes the logging for the service
@@ -45,19 +60,12 @@ func setupServer() *server.MCPServer {
 	)
 }
 
-func (s *Service) initLSPClient() error {
-	s.clientMutex.Lock()
-	defer s.clientMutex.Unlock()
-
-	if s.lspClient != nil {
-		log.Println("Closing existing LSP client before reinitializing...")
-		s.lspClient.Close()
-		s.lspClient = nil
-	}
-
-	lspClient, err := client.NewGoplsClient()
+// newInitializedClient starts a gopls client with the given settings and
+// runs the initialize handshake
+func newInitializedClient(settings map[string]any) (client.LSPClient, error) {
+	lspClient, err := client.NewGoplsClient(settings)
 	if err != nil {
-		return fmt.Errorf("failed to create LSP client: %w", err)
+		return nil, fmt.Errorf("failed to create LSP client: %w", err)
 	}
 
 	log.Println("LSP client created, initializing...")
@@ -74,7 +82,27 @@ func (s *Service) initLSPClient() error {
 
 	if initErr != nil {
 		lspClient.Close()
-		return fmt.Errorf("failed to initialize LSP client after multiple attempts: %w", initErr)
+		return nil, fmt.Errorf("failed to initialize LSP client after multiple attempts: %w", initErr)
+	}
+
+	return lspClient, nil
+}
+
+func (s *Service) initLSPClient() error {
+	s.clientMutex.Lock()
+	defer s.clientMutex.Unlock()
+
+	if s.lspClient != nil {
+		log.Println("Closing existing LSP client before reinitializing...")
+		// Keep settings changed at runtime for the new client
+		s.goplsSettings = s.lspClient.Settings()
+		s.lspClient.Close()
+		s.lspClient = nil
+	}
+
+	lspClient, err := newInitializedClient(s.goplsSettings)
+	if err != nil {
+		return err
 	}
 
 	log.Println("LSP client successfully initialized")
@@ -102,6 +130,87 @@ func (s *Service) GetLSPClient() client.LSPClient {
 	return s.lspClient
 }
 
+// GetConfigClient returns the gopls client dedicated to a build
+// configuration, starting it on first use. Clients are shared by the
+// configurations with the same settings and are never closed while running,
+// so calls in flight keep a working client; a client that has been closed is
+// replaced. gopls is started outside of the lock so that configurations start
+// in parallel.
+func (s *Service) GetConfigClient(label string, settings map[string]any) (client.LSPClient, error) {
+	data, err := json.Marshal(settings)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode settings for %s: %w", label, err)
+	}
+	key := string(data)
+
+	if existing := s.runningConfigClient(key); existing != nil {
+		return existing, nil
+	}
+
+	log.Printf("Starting gopls for build configuration %s", label)
+	lspClient, err := newInitializedClient(settings)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start gopls for %s: %w", label, err)
+	}
+
+	s.configMutex.Lock()
+	if s.configClients == nil {
+		s.configClients = make(map[string]*configClient)
+	}
+	if existing, ok := s.configClients[key]; ok && !existing.client.IsClosed() {
+		// Another call started the same configuration meanwhile
+		s.configMutex.Unlock()
+		lspClient.Close()
+		return existing.client, nil
+	}
+	s.configClients[key] = &configClient{client: lspClient, label: label}
+	s.configMutex.Unlock()
+
+	return lspClient, nil
+}
+
+// runningConfigClient returns the running client started with the given
+// settings, or nil. A client that has been closed is forgotten.
+func (s *Service) runningConfigClient(key string) client.LSPClient {
+	s.configMutex.Lock()
+	defer s.configMutex.Unlock()
+
+	existing, ok := s.configClients[key]
+	if !ok {
+		return nil
+	}
+	if existing.client.IsClosed() {
+		log.Printf("gopls for build configuration %s was closed, restarting it", existing.label)
+		delete(s.configClients, key)
+		return nil
+	}
+	return existing.client
+}
+
+// Close stops every gopls instance and closes the log file
+func (s *Service) Close() {
+	s.configMutex.Lock()
+	configClients := s.configClients
+	s.configClients = nil
+	s.configMutex.Unlock()
+
+	for _, configClient := range configClients {
+		log.Printf("Closing gopls for build configuration %s", configClient.label)
+		configClient.client.Close()
+	}
+
+	s.clientMutex.Lock()
+	if s.lspClient != nil {
+		s.lspClient.Close()
+		s.lspClient = nil
+	}
+	s.clientMutex.Unlock()
+
+	if s.logFile != nil {
+		s.logFile.Close()
+	}
+}
+
 func (s *Service) RegisterTools() {
 	log.Println("Registering LSP tools...")
 	lspTools := tools.NewLSPTools(s.lspClient)
@@ -114,6 +223,9 @@ func (s *Service) RegisterTools() {
 		return s.resetLSPClientIfNeeded(err)
 	})
 	log.Println("LSP client reset configured")
+	lspTools.SetConfigClientGetter(func(label string, settings map[string]any) (client.LSPClient, error) {
+		return s.GetConfigClient(label, settings)
+	})
 	lspTools.Register(s.server)
 	log.Println("LSP tools registered")
 }
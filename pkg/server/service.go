@@ -4,14 +4,15 @@ package server
 // adapter to the implementations in server.go
 
 // NewService creates a new MCP service for gopls integration
-func NewService() (*Service, error) {
+func NewService(cfg Config) (*Service, error) {
 	logFile, err := setupLogger()
 	if err != nil {
 		return nil, err
 	}
 
 	svc := &Service{
-		logFile: logFile,
+		logFile:       logFile,
+		goplsSettings: cfg.GoplsSettings,
 	}
 
 	if err := svc.initLSPClient(); err != nil {
@@ -0,0 +1,376 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/solatis/mcp-gopls/pkg/diff"
+	"github.com/solatis/mcp-gopls/pkg/lsp/client"
+	"github.com/solatis/mcp-gopls/pkg/lsp/protocol"
+)
+
+// fileEdits collects the edits of one file: range edits, relative to the
+// original content, then search/replace edits applied in order
+type fileEdits struct {
+	URI      string
+	Ranges   []protocol.TextEdit
+	Replaces []searchReplace
+}
+
+type searchReplace struct {
+	Search     string
+	Replace    string
+	ReplaceAll bool
+}
+
+type editedFile struct {
+	URI         string                `json:"uri"`
+	Diff        string                `json:"diff,omitempty"`
+	Diagnostics []protocol.Diagnostic `json:"diagnostics"`
+	// NewErrors are the errors not reported before the edit
+	NewErrors []protocol.Diagnostic `json:"new_errors,omitempty"`
+}
+
+// affectedFile is a file the edit did not touch, in the package of an edited
+// file or in a package importing it, with the errors the edit introduced
+type affectedFile struct {
+	URI       string                `json:"uri"`
+	NewErrors []protocol.Diagnostic `json:"new_errors"`
+}
+
+type applyEditsResult struct {
+	Files         []editedFile   `json:"files"`
+	AffectedFiles []affectedFile `json:"affected_files,omitempty"`
+	RolledBack    bool           `json:"rolled_back"`
+}
+
+// parseEdits reads the "edits" tool argument, grouping the edits per file in
+// the order the files first appear
+func parseEdits(args map[string]any, defaultURI string) ([]*fileEdits, error) {
+	items, ok := args["edits"].([]any)
+	if !ok || len(items) == 0 {
+		return nil, errors.New("edits must be a non-empty array")
+	}
+
+	var files []*fileEdits
+	byURI := make(map[string]*fileEdits)
+
+	for i, item := range items {
+		obj, ok := item.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("edit %d must be an object", i)
+		}
+
+		uri, _ := obj["file_uri"].(string)
+		if uri == "" {
+			uri = defaultURI
+		}
+		if uri == "" {
+			return nil, fmt.Errorf("edit %d has no file_uri", i)
+		}
+		if !strings.HasPrefix(uri, "file://") {
+			uri = convertPathToURI(uri)
+		}
+
+		file, ok := byURI[uri]
+		if !ok {
+			file = &fileEdits{URI: uri}
+			byURI[uri] = file
+			files = append(files, file)
+		}
+
+		newText, _ := obj["new_text"].(string)
+		if rangeObj, ok := obj["range"].(map[string]any); ok {
+			start, err := parsePosition(rangeObj["start"])
+			if err != nil {
+				return nil, fmt.Errorf("edit %d: invalid range start: %w", i, err)
+			}
+			end, err := parsePosition(rangeObj["end"])
+			if err != nil {
+				return nil, fmt.Errorf("edit %d: invalid range end: %w", i, err)
+			}
+			file.Ranges = append(file.Ranges, protocol.TextEdit{
+				Range:   protocol.Range{Start: start, End: end},
+				NewText: newText,
+			})
+			continue
+		}
+
+		search, _ := obj["search"].(string)
+		if search == "" {
+			return nil, fmt.Errorf("edit %d needs either a range or a search string", i)
+		}
+		replace, _ := obj["replace"].(string)
+		replaceAll, _ := obj["replace_all"].(bool)
+		file.Replaces = append(file.Replaces, searchReplace{Search: search, Replace: replace, ReplaceAll: replaceAll})
+	}
+
+	return files, nil
+}
+
+// apply returns the content with the edits applied
+func (f *fileEdits) apply(content string) (string, error) {
+	updated, err := protocol.ApplyTextEdits(content, f.Ranges)
+	if err != nil {
+		return "", err
+	}
+
+	for _, r := range f.Replaces {
+		count := strings.Count(updated, r.Search)
+		switch {
+		case count == 0:
+			return "", fmt.Errorf("search string not found: %q", r.Search)
+		case count > 1 && !r.ReplaceAll:
+			return "", fmt.Errorf("search string found %d times, make it unique or set replace_all: %q", count, r.Search)
+		}
+		updated = strings.ReplaceAll(updated, r.Search, r.Replace)
+	}
+
+	return updated, nil
+}
+
+// newErrors returns the errors of after that before does not have, comparing
+// source and message only, so that errors moved by the edit are not new
+func newErrors(before, after []protocol.Diagnostic) []protocol.Diagnostic {
+	key := func(d protocol.Diagnostic) string {
+		return d.Source + "\x00" + d.Message
+	}
+
+	existing := make(map[string]int)
+	for _, d := range before {
+		existing[key(d)]++
+	}
+
+	var added []protocol.Diagnostic
+	for _, d := range after {
+		if d.Severity != int(protocol.SeverityError) && d.Severity != 0 {
+			continue
+		}
+		if existing[key(d)] > 0 {
+			existing[key(d)]--
+			continue
+		}
+		added = append(added, d)
+	}
+	return added
+}
+
+func (t *LSPTools) registerApplyEdits(s *server.MCPServer) {
+	applyEditsTool := mcp.NewTool("apply_edits",
+		mcp.WithDescription("EDIT FILES AND VERIFY IN ONE STEP: Use this tool to change Go files instead of writing them and then calling check_diagnostics. Applies a list of edits (a range with new text, or a search string with its replacement), tells gopls about the new content and waits for its fresh diagnostics. Returns the diff and diagnostics of every edited file, highlighting the errors the edit introduced, along with the errors it introduced in other files of the edited packages and of the packages importing them, e.g. callers broken by a signature change. Can roll the edit back automatically when it introduces errors anywhere."),
+		mcp.WithString("file_uri",
+			mcp.Description("URI or absolute path of the file to edit, for edits that do not give their own file_uri"),
+		),
+		mcp.WithArray("edits",
+			mcp.Required(),
+			mcp.Description("Edits to apply. Each is an object with an optional 'file_uri' and either 'range' ({'start': {'line', 'character'}, 'end': {...}}, 0-indexed) with 'new_text', or 'search' with 'replace' (and 'replace_all' to replace every occurrence; otherwise the search string must be unique). Ranges refer to the file before any edit; search/replace edits run in order after them"),
+			mcp.Items(map[string]any{"type": "object"}),
+		),
+		mcp.WithBoolean("rollback_on_error",
+			mcp.Description("Restore the files if the edit introduces new errors (default false)"),
+		),
+	)
+
+	s.AddTool(applyEditsTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		defaultURI := request.GetString("file_uri", "")
+		files, err := parseEdits(request.GetArguments(), defaultURI)
+		if err != nil {
+			return nil, err
+		}
+
+		lspClient := t.getClient()
+		if lspClient == nil {
+			return nil, errors.New("LSP client not available")
+		}
+
+		// Compute every change before writing anything, so that an invalid
+		// edit leaves all files alone
+		originals := make(map[string]string)
+		updates := make(map[string]string)
+		created := make(map[string]bool)
+		for _, file := range files {
+			content, err := os.ReadFile(protocol.URIToPath(file.URI))
+			if os.IsNotExist(err) {
+				created[file.URI] = true
+			} else if err != nil {
+				return nil, fmt.Errorf("failed to read file: %w", err)
+			}
+			updated, err := file.apply(string(content))
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", protocol.URIToPath(file.URI), err)
+			}
+			originals[file.URI] = string(content)
+			updates[file.URI] = updated
+		}
+
+		before := make(map[string][]protocol.Diagnostic)
+		for _, file := range files {
+			if created[file.URI] {
+				continue
+			}
+			diagnostics, err := lspClient.GetDiagnostics(file.URI)
+			if err != nil {
+				return nil, t.handleLSPError(err)
+			}
+			before[file.URI] = diagnostics
+		}
+
+		// Errors of the other files of the affected packages, once gopls has
+		// diagnosed them
+		affected := t.affectedDirs(ctx, files)
+		lspClient.WaitForDiagnosticsIdle(diagnosticsQuietPeriod, workspaceDiagnosticsTimeout)
+		beforeAffected := affectedDiagnostics(lspClient.WorkspaceDiagnostics(), affected, updates)
+
+		if err := writeDocuments(lspClient, files, updates); err != nil {
+			return nil, t.handleLSPError(err)
+		}
+
+		result := applyEditsResult{Files: []editedFile{}}
+		introduced := false
+		for _, file := range files {
+			diagnostics, err := lspClient.GetDiagnostics(file.URI)
+			if err != nil {
+				return nil, t.handleLSPError(err)
+			}
+
+			name := filepath.Base(protocol.URIToPath(file.URI))
+			edited := editedFile{
+				URI:         file.URI,
+				Diff:        diff.Unified("a/"+name, "b/"+name, originals[file.URI], updates[file.URI]),
+				Diagnostics: diagnostics,
+				NewErrors:   newErrors(before[file.URI], diagnostics),
+			}
+			if len(edited.NewErrors) > 0 {
+				introduced = true
+			}
+			result.Files = append(result.Files, edited)
+		}
+
+		lspClient.WaitForDiagnosticsIdle(diagnosticsQuietPeriod, workspaceDiagnosticsTimeout)
+		for uri, diagnostics := range affectedDiagnostics(lspClient.WorkspaceDiagnostics(), affected, updates) {
+			if added := newErrors(beforeAffected[uri], diagnostics); len(added) > 0 {
+				result.AffectedFiles = append(result.AffectedFiles, affectedFile{URI: uri, NewErrors: added})
+				introduced = true
+			}
+		}
+		sort.Slice(result.AffectedFiles, func(i, j int) bool {
+			return result.AffectedFiles[i].URI < result.AffectedFiles[j].URI
+		})
+
+		if introduced && request.GetBool("rollback_on_error", false) {
+			if err := writeDocuments(lspClient, files, originals); err != nil {
+				return nil, t.handleLSPError(fmt.Errorf("failed to roll back: %w", err))
+			}
+			for uri := range created {
+				if err := os.Remove(protocol.URIToPath(uri)); err != nil {
+					return nil, fmt.Errorf("failed to roll back: %w", err)
+				}
+				if err := lspClient.DidClose(uri); err != nil {
+					return nil, t.handleLSPError(err)
+				}
+			}
+			result.RolledBack = true
+		}
+
+		data, err := json.Marshal(result)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal result: %w", err)
+		}
+
+		return mcp.NewToolResultText(string(data)), nil
+	})
+}
+
+// writeDocuments writes the contents to disk and sends them to gopls as new
+// versions of the documents
+func writeDocuments(lspClient client.LSPClient, files []*fileEdits, contents map[string]string) error {
+	for _, file := range files {
+		path := protocol.URIToPath(file.URI)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return fmt.Errorf("failed to create directory for %s: %w", path, err)
+		}
+		if err := os.WriteFile(path, []byte(contents[file.URI]), 0o644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", path, err)
+		}
+		if err := lspClient.DidChange(file.URI, contents[file.URI]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// affectedDirs returns the directories of the packages of the edited files and
+// of the workspace packages importing them, directly or not. Only the edited
+// directories are returned when the packages cannot be listed.
+func (t *LSPTools) affectedDirs(ctx context.Context, files []*fileEdits) map[string]bool {
+	dirs := make(map[string]bool)
+	for _, file := range files {
+		dirs[filepath.Dir(protocol.URIToPath(file.URI))] = true
+	}
+
+	root := moduleRoot(filepath.Dir(protocol.URIToPath(files[0].URI)))
+	if root == "" {
+		return dirs
+	}
+	packages, err := t.goRunner().ListPackages(ctx, root, false, "./...")
+	if err != nil {
+		log.Printf("⚠️ Failed to list the packages importing the edited files: %v", err)
+		return dirs
+	}
+
+	packageDirs := make(map[string]string)
+	var edited []string
+	for _, pkg := range packages {
+		packageDirs[pkg.ImportPath] = pkg.Dir
+		if dirs[pkg.Dir] {
+			edited = append(edited, pkg.ImportPath)
+		}
+	}
+
+	reverse := newPackageGraph(packages, map[string]bool{packageKindWorkspace: true}).importers()
+	for _, path := range edited {
+		for dependent := range reachable(path, func(path string) []string { return reverse[path] }) {
+			dirs[packageDirs[dependent]] = true
+		}
+	}
+	return dirs
+}
+
+// affectedDiagnostics returns the published diagnostics of the files in dirs,
+// leaving out the edited files
+func affectedDiagnostics(published map[string][]protocol.Diagnostic, dirs map[string]bool, edited map[string]string) map[string][]protocol.Diagnostic {
+	affected := make(map[string][]protocol.Diagnostic)
+	for uri, diagnostics := range published {
+		if _, ok := edited[uri]; ok {
+			continue
+		}
+		if dirs[filepath.Dir(protocol.URIToPath(uri))] {
+			affected[uri] = diagnostics
+		}
+	}
+	return affected
+}
+
+// moduleRoot returns the directory of the go.mod file governing dir, or ""
+func moduleRoot(dir string) string {
+	for {
+		if _, err := os.Stat(filepath.Join(dir, "go.mod")); err == nil {
+			return dir
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
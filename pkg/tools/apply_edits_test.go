@@ -0,0 +1,192 @@
+package tools
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/solatis/mcp-gopls/pkg/lsp/protocol"
+)
+
+func TestParseEdits(t *testing.T) {
+	position := func(line, character float64) map[string]any {
+		return map[string]any{"line": line, "character": character}
+	}
+
+	tests := []struct {
+		name    string
+		edits   []any
+		want    []*fileEdits
+		wantErr string
+	}{
+		{
+			name: "grouped per file in order",
+			edits: []any{
+				map[string]any{"file_uri": "file:///b.go", "search": "x", "replace": "y"},
+				map[string]any{"range": map[string]any{"start": position(1, 0), "end": position(1, 3)}, "new_text": "var"},
+				map[string]any{"file_uri": "file:///b.go", "search": "z", "replace_all": true},
+			},
+			want: []*fileEdits{
+				{
+					URI:      "file:///b.go",
+					Replaces: []searchReplace{{Search: "x", Replace: "y"}, {Search: "z", ReplaceAll: true}},
+				},
+				{
+					URI: "file:///a.go",
+					Ranges: []protocol.TextEdit{{
+						Range:   protocol.Range{Start: protocol.Position{Line: 1}, End: protocol.Position{Line: 1, Character: 3}},
+						NewText: "var",
+					}},
+				},
+			},
+		},
+		{
+			name:  "path converted to URI",
+			edits: []any{map[string]any{"file_uri": "/src/c.go", "search": "x"}},
+			want:  []*fileEdits{{URI: "file:///src/c.go", Replaces: []searchReplace{{Search: "x"}}}},
+		},
+		{
+			name:    "neither range nor search",
+			edits:   []any{map[string]any{"new_text": "x"}},
+			wantErr: "needs either a range or a search string",
+		},
+		{
+			name:    "invalid range",
+			edits:   []any{map[string]any{"range": map[string]any{"start": position(0, 0)}}},
+			wantErr: "invalid range end",
+		},
+		{
+			name:    "no edits",
+			edits:   []any{},
+			wantErr: "non-empty array",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseEdits(map[string]any{"edits": tt.edits}, "file:///a.go")
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("parseEdits() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseEdits() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseEdits() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFileEditsApply(t *testing.T) {
+	content := "package a\n\nvar x = 1\nvar y = x\n"
+	rangeOn := func(line, start, end int) protocol.Range {
+		return protocol.Range{
+			Start: protocol.Position{Line: line, Character: start},
+			End:   protocol.Position{Line: line, Character: end},
+		}
+	}
+
+	tests := []struct {
+		name    string
+		edits   fileEdits
+		want    string
+		wantErr string
+	}{
+		{
+			name: "range edits before search",
+			edits: fileEdits{
+				// The range refers to the original content, the search to
+				// the content after the range edit
+				Ranges:   []protocol.TextEdit{{Range: rangeOn(2, 4, 5), NewText: "z"}},
+				Replaces: []searchReplace{{Search: "var z = 1", Replace: "var z = 2"}},
+			},
+			want: "package a\n\nvar z = 2\nvar y = x\n",
+		},
+		{
+			name:  "search applied in order",
+			edits: fileEdits{Replaces: []searchReplace{{Search: "y = x", Replace: "y = w"}, {Search: "y = w", Replace: "y = v"}}},
+			want:  "package a\n\nvar x = 1\nvar y = v\n",
+		},
+		{
+			name:    "search not found",
+			edits:   fileEdits{Replaces: []searchReplace{{Search: "var w", Replace: "var v"}}},
+			wantErr: "search string not found",
+		},
+		{
+			name:    "duplicate match",
+			edits:   fileEdits{Replaces: []searchReplace{{Search: "x", Replace: "w"}}},
+			wantErr: "found 2 times",
+		},
+		{
+			name:  "replace all",
+			edits: fileEdits{Replaces: []searchReplace{{Search: "x", Replace: "w", ReplaceAll: true}}},
+			want:  "package a\n\nvar w = 1\nvar y = w\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.edits.apply(content)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("apply() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("apply() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("apply() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewErrors(t *testing.T) {
+	diagnostic := func(line, severity int, message string) protocol.Diagnostic {
+		return protocol.Diagnostic{
+			Range:    protocol.Range{Start: protocol.Position{Line: line}, End: protocol.Position{Line: line}},
+			Severity: severity,
+			Source:   "compiler",
+			Message:  message,
+		}
+	}
+	const errorSeverity = int(protocol.SeverityError)
+
+	tests := []struct {
+		name   string
+		before []protocol.Diagnostic
+		after  []protocol.Diagnostic
+		want   []protocol.Diagnostic
+	}{
+		{
+			name:   "moved error is not new",
+			before: []protocol.Diagnostic{diagnostic(3, errorSeverity, "undefined: w")},
+			after:  []protocol.Diagnostic{diagnostic(5, errorSeverity, "undefined: w")},
+		},
+		{
+			name:   "second occurrence is new",
+			before: []protocol.Diagnostic{diagnostic(3, errorSeverity, "undefined: w")},
+			after:  []protocol.Diagnostic{diagnostic(3, errorSeverity, "undefined: w"), diagnostic(7, errorSeverity, "undefined: w")},
+			want:   []protocol.Diagnostic{diagnostic(7, errorSeverity, "undefined: w")},
+		},
+		{
+			name:  "warnings are ignored",
+			after: []protocol.Diagnostic{diagnostic(1, int(protocol.SeverityWarning), "unused"), diagnostic(2, 0, "no severity")},
+			want:  []protocol.Diagnostic{diagnostic(2, 0, "no severity")},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := newErrors(tt.before, tt.after); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("newErrors() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
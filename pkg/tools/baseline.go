@@ -0,0 +1,319 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/solatis/mcp-gopls/pkg/lsp/client"
+	"github.com/solatis/mcp-gopls/pkg/lsp/protocol"
+)
+
+const (
+	baselineModeSnapshot = "snapshot"
+	baselineModeCompare  = "compare"
+)
+
+// baselineEntry is a diagnostic recorded in a baseline. File is relative to
+// the baseline directory, with forward slashes, so that a baseline file can be
+// shared across checkouts.
+type baselineEntry struct {
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+	Severity string `json:"severity"`
+	Source   string `json:"source,omitempty"`
+	Code     string `json:"code,omitempty"`
+	Message  string `json:"message"`
+	// Context is the trimmed text of the line, which identifies the
+	// diagnostic when lines are inserted or removed above it
+	Context string `json:"context,omitempty"`
+}
+
+// key groups the entries that may be the same diagnostic
+func (e baselineEntry) key() string {
+	return e.File + "\x00" + e.Source + "\x00" + e.Code + "\x00" + e.Message
+}
+
+type diagnosticsBaseline struct {
+	Dir     string          `json:"dir"`
+	Created time.Time       `json:"created"`
+	Entries []baselineEntry `json:"entries"`
+}
+
+type baselineSnapshotResult struct {
+	Stored     string         `json:"stored"`
+	Count      int            `json:"count"`
+	BySeverity map[string]int `json:"by_severity"`
+}
+
+type baselineCompareResult struct {
+	BaselineCreated time.Time       `json:"baseline_created"`
+	New             []baselineEntry `json:"new"`
+	Fixed           []baselineEntry `json:"fixed"`
+	UnchangedCount  int             `json:"unchanged_count"`
+	Unchanged       []baselineEntry `json:"unchanged,omitempty"`
+}
+
+func (t *LSPTools) registerDiagnosticsBaseline(s *server.MCPServer) {
+	baselineTool := mcp.NewTool("diagnostics_baseline",
+		mcp.WithDescription("SEPARATE NEW WARNINGS FROM LEGACY ONES: Use this tool when a codebase already has many diagnostics (e.g. hundreds of staticcheck warnings) and you only care about the ones you add or fix. 'snapshot' records the current diagnostics of a directory, in memory or in a baseline file; 'compare' reports the diagnostics that are new since the snapshot, the ones fixed, and how many are unchanged. Diagnostics are matched by file, source, code and message, and by the text of their line, so that they still match after lines are inserted or removed above them."),
+		mcp.WithString("mode",
+			mcp.Required(),
+			mcp.Description("'snapshot' to record the current diagnostics, 'compare' to compare the current diagnostics with the last snapshot"),
+			mcp.Enum(baselineModeSnapshot, baselineModeCompare),
+		),
+		mcp.WithString("dir",
+			mcp.Description("Directory whose diagnostics are recorded, as a path or file:// URI (default: the server's working directory)"),
+		),
+		mcp.WithString("file",
+			mcp.Description("Baseline file to write or read, relative to dir or absolute, e.g. '.diagnostics-baseline.json'. Without it, the baseline is kept in memory until the server stops"),
+		),
+		mcp.WithString("min_severity",
+			mcp.Description("Least severe diagnostics to include (default 'hint', i.e. all)"),
+			mcp.Enum("error", "warning", "info", "hint"),
+		),
+		mcp.WithArray("sources",
+			mcp.Description("Only include diagnostics whose source equals or starts with one of these, e.g. [\"SA\", \"ST\"]"),
+			mcp.Items(map[string]any{"type": "string"}),
+		),
+		mcp.WithBoolean("include_unchanged",
+			mcp.Description("With compare, also list the unchanged diagnostics instead of only counting them (default false)"),
+		),
+	)
+
+	s.AddTool(baselineTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		mode := request.GetString("mode", "")
+		if mode != baselineModeSnapshot && mode != baselineModeCompare {
+			return nil, fmt.Errorf("unknown mode: %s", mode)
+		}
+
+		dir := request.GetString("dir", "")
+		if dir != "" {
+			dir = resolveDir(dir)
+		} else {
+			wd, err := os.Getwd()
+			if err != nil {
+				return nil, fmt.Errorf("failed to get working directory: %w", err)
+			}
+			dir = wd
+		}
+
+		file := request.GetString("file", "")
+		if file != "" && !filepath.IsAbs(file) {
+			file = filepath.Join(dir, file)
+		}
+
+		filter := diagnosticFilter{
+			Dir:     dir,
+			Sources: request.GetStringSlice("sources", nil),
+		}
+		if name := request.GetString("min_severity", ""); name != "" {
+			severity, ok := protocol.ParseDiagnosticSeverity(name)
+			if !ok {
+				return nil, fmt.Errorf("unknown severity: %s", name)
+			}
+			filter.MinSeverity = severity
+		}
+
+		lspClient := t.getClient()
+		if lspClient == nil {
+			return nil, errors.New("LSP client not available")
+		}
+
+		entries, err := currentBaselineEntries(lspClient, filter)
+		if err != nil {
+			return nil, t.handleLSPError(err)
+		}
+
+		var result any
+		if mode == baselineModeSnapshot {
+			baseline := &diagnosticsBaseline{Dir: dir, Created: time.Now().UTC(), Entries: entries}
+			snapshot := baselineSnapshotResult{Stored: "memory", Count: len(entries), BySeverity: make(map[string]int)}
+			for _, e := range entries {
+				snapshot.BySeverity[e.Severity]++
+			}
+
+			if file != "" {
+				data, err := json.MarshalIndent(baseline, "", "  ")
+				if err != nil {
+					return nil, fmt.Errorf("failed to marshal baseline: %w", err)
+				}
+				if err := os.WriteFile(file, append(data, '\n'), 0o644); err != nil {
+					return nil, fmt.Errorf("failed to write baseline: %w", err)
+				}
+				snapshot.Stored = file
+			} else {
+				t.baselinesMutex.Lock()
+				t.baselines[dir] = baseline
+				t.baselinesMutex.Unlock()
+			}
+			result = snapshot
+		} else {
+			var baseline *diagnosticsBaseline
+			if file != "" {
+				data, err := os.ReadFile(file)
+				if err != nil {
+					return nil, fmt.Errorf("failed to read baseline: %w", err)
+				}
+				baseline = &diagnosticsBaseline{}
+				if err := json.Unmarshal(data, baseline); err != nil {
+					return nil, fmt.Errorf("failed to decode baseline %s: %w", file, err)
+				}
+			} else {
+				t.baselinesMutex.Lock()
+				baseline = t.baselines[dir]
+				t.baselinesMutex.Unlock()
+				if baseline == nil {
+					return nil, fmt.Errorf("no baseline recorded for %s, take a snapshot first", dir)
+				}
+			}
+
+			compared := compareBaseline(baseline.Entries, entries)
+			compared.BaselineCreated = baseline.Created
+			if !request.GetBool("include_unchanged", false) {
+				compared.Unchanged = nil
+			}
+			result = compared
+		}
+
+		data, err := json.Marshal(result)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal result: %w", err)
+		}
+
+		return mcp.NewToolResultText(string(data)), nil
+	})
+}
+
+// currentBaselineEntries returns the diagnostics gopls reports for the files
+// under the filter directory, once it is done diagnosing them
+func currentBaselineEntries(lspClient client.LSPClient, filter diagnosticFilter) ([]baselineEntry, error) {
+	// Opening a file makes gopls load and diagnose its module
+	if goFile := findGoFile(filter.Dir); goFile != "" {
+		if _, err := lspClient.GetDiagnostics(convertPathToURI(goFile)); err != nil {
+			return nil, err
+		}
+	}
+	lspClient.WaitForDiagnosticsIdle(diagnosticsQuietPeriod, workspaceDiagnosticsTimeout)
+
+	entries := []baselineEntry{}
+	for _, summary := range collectWorkspaceDiagnostics(lspClient.WorkspaceDiagnostics(), filter, false).Files {
+		path := protocol.URIToPath(summary.URI)
+		rel, err := filepath.Rel(filter.Dir, path)
+		if err != nil {
+			rel = path
+		}
+
+		var lines []string
+		if content, err := os.ReadFile(path); err == nil {
+			lines = strings.Split(string(content), "\n")
+		}
+
+		for _, d := range summary.Diagnostics {
+			severity := protocol.DiagnosticSeverity(d.Severity)
+			if severity == 0 {
+				severity = protocol.SeverityError
+			}
+			entry := baselineEntry{
+				File:     filepath.ToSlash(rel),
+				Line:     d.Range.Start.Line,
+				Severity: severity.String(),
+				Source:   d.Source,
+				Code:     d.Code,
+				Message:  d.Message,
+			}
+			if d.Range.Start.Line < len(lines) {
+				entry.Context = strings.TrimSpace(lines[d.Range.Start.Line])
+			}
+			entries = append(entries, entry)
+		}
+	}
+
+	return entries, nil
+}
+
+// compareBaseline matches the current diagnostics with the baseline ones.
+// Entries with the same file, source, code and message match when the text
+// of their line is the same, the closest lines first, or failing that when
+// they are on the same line.
+func compareBaseline(baseline, current []baselineEntry) baselineCompareResult {
+	result := baselineCompareResult{
+		New:       []baselineEntry{},
+		Fixed:     []baselineEntry{},
+		Unchanged: []baselineEntry{},
+	}
+
+	remaining := make(map[string][]baselineEntry)
+	for _, e := range baseline {
+		remaining[e.key()] = append(remaining[e.key()], e)
+	}
+
+	var unmatched []baselineEntry
+	for _, e := range current {
+		candidates := remaining[e.key()]
+		best := -1
+		for i, c := range candidates {
+			if c.Context != e.Context {
+				continue
+			}
+			if best < 0 || abs(c.Line-e.Line) < abs(candidates[best].Line-e.Line) {
+				best = i
+			}
+		}
+		if best < 0 {
+			unmatched = append(unmatched, e)
+			continue
+		}
+		remaining[e.key()] = append(candidates[:best], candidates[best+1:]...)
+		result.Unchanged = append(result.Unchanged, e)
+	}
+
+	for _, e := range unmatched {
+		candidates := remaining[e.key()]
+		matched := false
+		for i, c := range candidates {
+			if c.Line == e.Line {
+				remaining[e.key()] = append(candidates[:i], candidates[i+1:]...)
+				matched = true
+				break
+			}
+		}
+		if matched {
+			result.Unchanged = append(result.Unchanged, e)
+		} else {
+			result.New = append(result.New, e)
+		}
+	}
+
+	for _, entries := range remaining {
+		result.Fixed = append(result.Fixed, entries...)
+	}
+
+	for _, entries := range [][]baselineEntry{result.New, result.Fixed, result.Unchanged} {
+		sort.Slice(entries, func(i, j int) bool {
+			if entries[i].File != entries[j].File {
+				return entries[i].File < entries[j].File
+			}
+			return entries[i].Line < entries[j].Line
+		})
+	}
+	result.UnchangedCount = len(result.Unchanged)
+
+	return result
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,89 @@
+package tools
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCompareBaseline(t *testing.T) {
+	unused := func(line int, context string) baselineEntry {
+		return baselineEntry{File: "a.go", Line: line, Severity: "warning", Source: "unusedvariable", Message: "declared and not used: x", Context: context}
+	}
+	undefined := func(file string, line int) baselineEntry {
+		return baselineEntry{File: file, Line: line, Severity: "error", Source: "compiler", Code: "UndeclaredName", Message: "undefined: y", Context: "y++"}
+	}
+
+	tests := []struct {
+		name               string
+		baseline, current  []baselineEntry
+		wantNew, wantFixed []baselineEntry
+		wantUnchanged      []baselineEntry
+	}{
+		{
+			name:          "lines inserted above",
+			baseline:      []baselineEntry{unused(10, "x := 1")},
+			current:       []baselineEntry{unused(14, "x := 1")},
+			wantNew:       []baselineEntry{},
+			wantFixed:     []baselineEntry{},
+			wantUnchanged: []baselineEntry{unused(14, "x := 1")},
+		},
+		{
+			name:          "line edited in place",
+			baseline:      []baselineEntry{unused(10, "x := 1")},
+			current:       []baselineEntry{unused(10, "x := 2")},
+			wantNew:       []baselineEntry{},
+			wantFixed:     []baselineEntry{},
+			wantUnchanged: []baselineEntry{unused(10, "x := 2")},
+		},
+		{
+			name:          "one of two identical diagnostics fixed",
+			baseline:      []baselineEntry{unused(10, "x := 1"), unused(20, "x := 1")},
+			current:       []baselineEntry{unused(22, "x := 1")},
+			wantNew:       []baselineEntry{},
+			wantFixed:     []baselineEntry{unused(10, "x := 1")},
+			wantUnchanged: []baselineEntry{unused(22, "x := 1")},
+		},
+		{
+			name:          "same message in another file",
+			baseline:      []baselineEntry{undefined("a.go", 5)},
+			current:       []baselineEntry{undefined("a.go", 5), undefined("b.go", 5)},
+			wantNew:       []baselineEntry{undefined("b.go", 5)},
+			wantFixed:     []baselineEntry{},
+			wantUnchanged: []baselineEntry{undefined("a.go", 5)},
+		},
+		{
+			name:          "moved and edited",
+			baseline:      []baselineEntry{unused(10, "x := 1")},
+			current:       []baselineEntry{unused(12, "x := 2")},
+			wantNew:       []baselineEntry{unused(12, "x := 2")},
+			wantFixed:     []baselineEntry{unused(10, "x := 1")},
+			wantUnchanged: []baselineEntry{},
+		},
+		{
+			name:          "sorted by file and line",
+			baseline:      nil,
+			current:       []baselineEntry{undefined("b.go", 1), unused(30, "x := 1"), unused(3, "x := 1")},
+			wantNew:       []baselineEntry{unused(3, "x := 1"), unused(30, "x := 1"), undefined("b.go", 1)},
+			wantFixed:     []baselineEntry{},
+			wantUnchanged: []baselineEntry{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := compareBaseline(tt.baseline, tt.current)
+			if !reflect.DeepEqual(result.New, tt.wantNew) {
+				t.Errorf("new = %v, want %v", result.New, tt.wantNew)
+			}
+			if !reflect.DeepEqual(result.Fixed, tt.wantFixed) {
+				t.Errorf("fixed = %v, want %v", result.Fixed, tt.wantFixed)
+			}
+			if !reflect.DeepEqual(result.Unchanged, tt.wantUnchanged) {
+				t.Errorf("unchanged = %v, want %v", result.Unchanged, tt.wantUnchanged)
+			}
+			if result.UnchangedCount != len(tt.wantUnchanged) {
+				t.Errorf("unchanged count = %d, want %d", result.UnchangedCount, len(tt.wantUnchanged))
+			}
+		})
+	}
+}
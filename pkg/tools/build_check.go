@@ -0,0 +1,167 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/solatis/mcp-gopls/pkg/gocmd"
+	"github.com/solatis/mcp-gopls/pkg/lsp/protocol"
+)
+
+// fileDiagnostic is a diagnostic together with the URI of its file
+type fileDiagnostic struct {
+	URI string `json:"uri"`
+	protocol.Diagnostic
+}
+
+// packageDiagnostics groups the diagnostics of a package
+type packageDiagnostics struct {
+	Package     string           `json:"package"`
+	Diagnostics []fileDiagnostic `json:"diagnostics"`
+}
+
+type buildCheckResult struct {
+	Success     bool                 `json:"success"`
+	Errors      int                  `json:"errors"`
+	Warnings    int                  `json:"warnings"`
+	Packages    []packageDiagnostics `json:"packages"`
+	OtherOutput []string             `json:"other_output,omitempty"`
+}
+
+func (t *LSPTools) registerBuildCheck(s *server.MCPServer) {
+	buildCheckTool := mcp.NewTool("build_check",
+		mcp.WithDescription("WHOLE-MODULE BUILD AND VET CHECK: Use this tool to answer 'does the whole module build and vet cleanly?' in one call. Runs 'go build' and 'go vet' over package patterns and returns every compiler error (severity 1) and vet finding (severity 2) as diagnostics with file URI and range, grouped per package. Use this when: 1) After changes spanning several packages, 2) Before committing, 3) check_diagnostics on single files is not enough. Uses the build flags and environment gopls is configured with."),
+		mcp.WithString("dir",
+			mcp.Required(),
+			mcp.Description("Directory to run the checks from, usually the module root, as a path or file:// URI"),
+		),
+		mcp.WithString("packages",
+			mcp.Description("Package pattern relative to dir (default './...')"),
+		),
+		mcp.WithBoolean("build",
+			mcp.Description("Run go build (default true)"),
+		),
+		mcp.WithBoolean("vet",
+			mcp.Description("Run go vet, which also type-checks test files (default true)"),
+		),
+	)
+
+	s.AddTool(buildCheckTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		dir := request.GetString("dir", "")
+		if dir == "" {
+			return nil, errors.New("dir is required")
+		}
+		dir = resolveDir(dir)
+		pattern := request.GetString("packages", "./...")
+
+		runner := t.goRunner()
+		var messages []gocmd.Message
+		var sources []string
+		var other []string
+		// A non-zero exit status without positioned messages, e.g. for a
+		// package that cannot be found, still means failure
+		failed := false
+
+		if request.GetBool("build", true) {
+			// Write binaries of main packages to a temporary directory
+			outDir, err := os.MkdirTemp("", "mcp-gopls-build")
+			if err != nil {
+				return nil, fmt.Errorf("failed to create build output directory: %w", err)
+			}
+			defer os.RemoveAll(outDir)
+
+			_, stderr, err := runner.Run(ctx, dir, "build", "-o", outDir, pattern)
+			failed = failed || err != nil
+			found, rest := gocmd.ParseBuildOutput(string(stderr), dir)
+			messages = append(messages, found...)
+			for range found {
+				sources = append(sources, "compiler")
+			}
+			other = append(other, rest...)
+		}
+
+		if request.GetBool("vet", true) {
+			_, stderr, err := runner.Run(ctx, dir, "vet", pattern)
+			failed = failed || err != nil
+			found, rest := gocmd.ParseBuildOutput(string(stderr), dir)
+			messages = append(messages, found...)
+			for range found {
+				sources = append(sources, "vet")
+			}
+			other = append(other, rest...)
+		}
+
+		result := buildCheckResult{
+			Packages:    []packageDiagnostics{},
+			OtherOutput: other,
+		}
+
+		index := make(map[string]int)
+		seen := make(map[string]bool)
+		for i, m := range messages {
+			key := fmt.Sprintf("%s:%d:%d:%s", m.File, m.Line, m.Column, m.Text)
+			if seen[key] {
+				// go vet reports the compiler errors go build already found
+				continue
+			}
+			seen[key] = true
+
+			d := messageToDiagnostic(m, sources[i])
+			if d.Severity == int(protocol.SeverityError) {
+				result.Errors++
+			} else {
+				result.Warnings++
+			}
+
+			// Recent versions of go vet do not print package headers
+			pkg := m.Package
+			if pkg == "" {
+				pkg = filepath.Dir(m.File)
+			}
+
+			j, ok := index[pkg]
+			if !ok {
+				j = len(result.Packages)
+				index[pkg] = j
+				result.Packages = append(result.Packages, packageDiagnostics{Package: pkg})
+			}
+			result.Packages[j].Diagnostics = append(result.Packages[j].Diagnostics, d)
+		}
+		result.Success = result.Errors == 0 && result.Warnings == 0 && !failed
+
+		data, err := json.Marshal(result)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal result: %w", err)
+		}
+
+		return mcp.NewToolResultText(string(data)), nil
+	})
+}
+
+// messageToDiagnostic converts a go build or go vet message to a diagnostic.
+// Compiler messages are errors, vet messages warnings, except for the type
+// errors vet reports when a package does not compile.
+func messageToDiagnostic(m gocmd.Message, source string) fileDiagnostic {
+	severity := protocol.SeverityError
+	if source == "vet" && !m.TypeError {
+		severity = protocol.SeverityWarning
+	}
+
+	pos := protocol.Position{Line: max(m.Line-1, 0), Character: max(m.Column-1, 0)}
+	return fileDiagnostic{
+		URI: convertPathToURI(m.File),
+		Diagnostic: protocol.Diagnostic{
+			Range:    protocol.Range{Start: pos, End: pos},
+			Severity: int(severity),
+			Source:   source,
+			Message:  m.Text,
+		},
+	}
+}
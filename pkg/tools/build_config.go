@@ -0,0 +1,177 @@
+package tools
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/solatis/mcp-gopls/pkg/lsp/protocol"
+)
+
+// buildConfig is a GOOS/GOARCH/build tags combination to analyze code with
+type buildConfig struct {
+	GOOS   string   `json:"goos,omitempty"`
+	GOARCH string   `json:"goarch,omitempty"`
+	Tags   []string `json:"tags,omitempty"`
+}
+
+// configDiagnostic is a diagnostic along with the build configurations that
+// reported it
+type configDiagnostic struct {
+	protocol.Diagnostic
+	Configurations []string `json:"configurations"`
+}
+
+func (c buildConfig) label() string {
+	goos, goarch := c.GOOS, c.GOARCH
+	if goos == "" {
+		goos = runtime.GOOS
+	}
+	if goarch == "" {
+		goarch = runtime.GOARCH
+	}
+
+	label := goos + "/" + goarch
+	if len(c.Tags) > 0 {
+		label += " tags=" + strings.Join(c.Tags, ",")
+	}
+	return label
+}
+
+// settings derives the gopls settings for the configuration from the base
+// settings: GOOS/GOARCH are set in env, tags replace any -tags build flag.
+func (c buildConfig) settings(base map[string]any) map[string]any {
+	settings := make(map[string]any, len(base)+2)
+	for k, v := range base {
+		settings[k] = v
+	}
+
+	env := map[string]any{}
+	if baseEnv, ok := base["env"].(map[string]any); ok {
+		for k, v := range baseEnv {
+			env[k] = v
+		}
+	}
+	if c.GOOS != "" {
+		env["GOOS"] = c.GOOS
+	}
+	if c.GOARCH != "" {
+		env["GOARCH"] = c.GOARCH
+	}
+	settings["env"] = env
+
+	if len(c.Tags) > 0 {
+		var buildFlags []any
+		if baseFlags, ok := base["buildFlags"].([]any); ok {
+			for _, flag := range baseFlags {
+				if s, ok := flag.(string); ok && (strings.HasPrefix(s, "-tags") || strings.HasPrefix(s, "--tags")) {
+					continue
+				}
+				buildFlags = append(buildFlags, flag)
+			}
+		}
+		settings["buildFlags"] = append(buildFlags, "-tags="+strings.Join(c.Tags, ","))
+	}
+
+	return settings
+}
+
+func parseBuildConfigs(args map[string]any) ([]buildConfig, error) {
+	raw, ok := args["configurations"]
+	if !ok || raw == nil {
+		return nil, nil
+	}
+
+	items, ok := raw.([]any)
+	if !ok {
+		return nil, errors.New("configurations must be an array")
+	}
+
+	configs := make([]buildConfig, 0, len(items))
+	for _, item := range items {
+		obj, ok := item.(map[string]any)
+		if !ok {
+			return nil, errors.New("each configuration must be an object with 'goos', 'goarch' and/or 'tags'")
+		}
+
+		var config buildConfig
+		config.GOOS, _ = obj["goos"].(string)
+		config.GOARCH, _ = obj["goarch"].(string)
+		if tags, ok := obj["tags"].([]any); ok {
+			for _, tag := range tags {
+				if s, ok := tag.(string); ok && s != "" {
+					config.Tags = append(config.Tags, s)
+				}
+			}
+		}
+		configs = append(configs, config)
+	}
+
+	return configs, nil
+}
+
+// diagnosticsForConfigs collects the diagnostics of a file in every build
+// configuration, each analyzed by a dedicated gopls instance, and merges
+// identical diagnostics. A non-nil content is analyzed instead of the file on
+// disk.
+func (t *LSPTools) diagnosticsForConfigs(uri string, content *string, configs []buildConfig) ([]configDiagnostic, error) {
+	if t.configClientGetter == nil {
+		return nil, errors.New("build configurations are not supported")
+	}
+
+	lspClient := t.getClient()
+	if lspClient == nil {
+		return nil, errors.New("LSP client not available")
+	}
+	base := lspClient.Settings()
+
+	results := make([][]protocol.Diagnostic, len(configs))
+	errs := make([]error, len(configs))
+
+	var wg sync.WaitGroup
+	for i, config := range configs {
+		wg.Add(1)
+		go func(i int, config buildConfig) {
+			defer wg.Done()
+
+			configClient, err := t.configClientGetter(config.label(), config.settings(base))
+			if err != nil {
+				errs[i] = fmt.Errorf("%s: %w", config.label(), err)
+				return
+			}
+
+			errs[i] = withOverlay(configClient, uri, content, func() (err error) {
+				results[i], err = configClient.GetDiagnostics(uri)
+				return err
+			})
+			if errs[i] != nil {
+				errs[i] = fmt.Errorf("%s: %w", config.label(), errs[i])
+			}
+		}(i, config)
+	}
+	wg.Wait()
+
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+
+	merged := []configDiagnostic{}
+	index := make(map[string]int)
+	for i, diagnostics := range results {
+		label := configs[i].label()
+		for _, d := range diagnostics {
+			key := fmt.Sprintf("%d:%d-%d:%d|%d|%s|%s|%s", d.Range.Start.Line, d.Range.Start.Character,
+				d.Range.End.Line, d.Range.End.Character, d.Severity, d.Code, d.Source, d.Message)
+			if j, ok := index[key]; ok {
+				merged[j].Configurations = append(merged[j].Configurations, label)
+				continue
+			}
+			index[key] = len(merged)
+			merged = append(merged, configDiagnostic{Diagnostic: d, Configurations: []string{label}})
+		}
+	}
+
+	return merged, nil
+}
@@ -0,0 +1,225 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/solatis/mcp-gopls/pkg/gitcmd"
+	"github.com/solatis/mcp-gopls/pkg/lsp/protocol"
+)
+
+const (
+	changedScopeLines = "lines"
+	changedScopeFiles = "files"
+)
+
+type changedFileDiagnostics struct {
+	URI string `json:"uri"`
+	New bool   `json:"new,omitempty"`
+	// ChangedLines are 0-indexed, as LSP positions
+	ChangedLines []gitcmd.LineRange    `json:"changed_lines,omitempty"`
+	Diagnostics  []protocol.Diagnostic `json:"diagnostics"`
+}
+
+type checkChangedResult struct {
+	Base            string                   `json:"base"`
+	Total           int                      `json:"total"`
+	Files           []changedFileDiagnostics `json:"files"`
+	ChangedPackages []string                 `json:"changed_packages"`
+	// DependentPackages import a changed package, directly or not
+	DependentPackages []string `json:"dependent_packages,omitempty"`
+	// DependentErrors are the errors of the dependent packages, which the
+	// change may have caused
+	DependentErrors []fileDiagnosticsSummary `json:"dependent_errors,omitempty"`
+}
+
+func (t *LSPTools) registerCheckChanged(s *server.MCPServer) {
+	checkChangedTool := mcp.NewTool("check_changed",
+		mcp.WithDescription("DID MY CHANGES BREAK ANYTHING? Use this tool on a feature branch or after editing files to get only the problems you introduced, instead of every diagnostic of the repository. Finds the Go files changed in the local git repository versus a base ref (or the uncommitted changes), checks them with gopls and reports the diagnostics on changed lines, or anywhere in the changed files. Also reports the errors of the packages importing the changed ones, since changing a signature breaks its callers."),
+		mcp.WithString("dir",
+			mcp.Description("Module directory inside the git repository, as a path or file:// URI (default: the server's working directory). Only changed files under it are checked"),
+		),
+		mcp.WithString("base",
+			mcp.Description("Branch, tag or commit to compare with, e.g. 'main'. Changes are taken from where HEAD forked from it, and include uncommitted and untracked files. Default: only the uncommitted changes, versus HEAD"),
+		),
+		mcp.WithString("scope",
+			mcp.Description("Report diagnostics on changed lines only, or anywhere in changed files (default 'lines')"),
+			mcp.Enum(changedScopeLines, changedScopeFiles),
+		),
+		mcp.WithString("min_severity",
+			mcp.Description("Least severe diagnostics to include (default 'hint', i.e. all)"),
+			mcp.Enum("error", "warning", "info", "hint"),
+		),
+		mcp.WithBoolean("include_dependents",
+			mcp.Description("Also report the errors of packages importing the changed packages (default true)"),
+		),
+	)
+
+	s.AddTool(checkChangedTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		dir := request.GetString("dir", "")
+		if dir != "" {
+			dir = resolveDir(dir)
+		} else {
+			wd, err := os.Getwd()
+			if err != nil {
+				return nil, fmt.Errorf("failed to get working directory: %w", err)
+			}
+			dir = wd
+		}
+
+		scope := request.GetString("scope", changedScopeLines)
+		if scope != changedScopeLines && scope != changedScopeFiles {
+			return nil, fmt.Errorf("unknown scope: %s", scope)
+		}
+
+		filter := diagnosticFilter{}
+		if name := request.GetString("min_severity", ""); name != "" {
+			severity, ok := protocol.ParseDiagnosticSeverity(name)
+			if !ok {
+				return nil, fmt.Errorf("unknown severity: %s", name)
+			}
+			filter.MinSeverity = severity
+		}
+
+		base := "HEAD"
+		if ref := request.GetString("base", ""); ref != "" {
+			mergeBase, err := gitcmd.MergeBase(ctx, dir, ref)
+			if err != nil {
+				return nil, fmt.Errorf("failed to find base: %w", err)
+			}
+			base = mergeBase
+		}
+
+		changed, err := gitcmd.ChangedFiles(ctx, dir, base)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list changed files: %w", err)
+		}
+
+		lspClient := t.getClient()
+		if lspClient == nil {
+			return nil, errors.New("LSP client not available")
+		}
+
+		result := checkChangedResult{
+			Base:            base,
+			Files:           []changedFileDiagnostics{},
+			ChangedPackages: []string{},
+		}
+		changedDirs := make(map[string]bool)
+
+		for _, file := range changed {
+			if !strings.HasSuffix(file.Path, ".go") || !isUnder(file.Path, dir) {
+				continue
+			}
+			if _, err := os.Stat(file.Path); err != nil {
+				continue
+			}
+			changedDirs[filepath.Dir(file.Path)] = true
+
+			uri := convertPathToURI(file.Path)
+			diagnostics, err := lspClient.GetDiagnostics(uri)
+			if err != nil {
+				return nil, t.handleLSPError(err)
+			}
+
+			entry := changedFileDiagnostics{
+				URI:         uri,
+				New:         file.New,
+				Diagnostics: []protocol.Diagnostic{},
+			}
+			for _, r := range file.Lines {
+				entry.ChangedLines = append(entry.ChangedLines, gitcmd.LineRange{Start: r.Start - 1, End: r.End - 1})
+			}
+			for _, d := range diagnostics {
+				if !filter.match(d) {
+					continue
+				}
+				if scope == changedScopeLines && !touchesChangedLines(file, d.Range) {
+					continue
+				}
+				entry.Diagnostics = append(entry.Diagnostics, d)
+			}
+			result.Total += len(entry.Diagnostics)
+			result.Files = append(result.Files, entry)
+		}
+
+		if len(changedDirs) > 0 {
+			packages, err := t.goRunner().ListPackages(ctx, dir, false, "./...")
+			if err != nil {
+				return nil, fmt.Errorf("failed to list packages: %w", err)
+			}
+
+			dirs := make(map[string]string)
+			for _, pkg := range packages {
+				dirs[pkg.ImportPath] = pkg.Dir
+				if changedDirs[pkg.Dir] {
+					result.ChangedPackages = append(result.ChangedPackages, pkg.ImportPath)
+				}
+			}
+			sort.Strings(result.ChangedPackages)
+
+			if request.GetBool("include_dependents", true) {
+				g := newPackageGraph(packages, map[string]bool{packageKindWorkspace: true})
+				reverse := g.importers()
+				dependents := make(map[string]bool)
+				for _, path := range result.ChangedPackages {
+					for dependent := range reachable(path, func(path string) []string { return reverse[path] }) {
+						dependents[dependent] = true
+					}
+				}
+
+				dependentDirs := make(map[string]bool)
+				for path := range dependents {
+					if changedDirs[dirs[path]] {
+						continue
+					}
+					result.DependentPackages = append(result.DependentPackages, path)
+					dependentDirs[dirs[path]] = true
+				}
+				sort.Strings(result.DependentPackages)
+
+				if len(dependentDirs) > 0 {
+					lspClient.WaitForDiagnosticsIdle(diagnosticsQuietPeriod, workspaceDiagnosticsTimeout)
+					errorsOnly := diagnosticFilter{MinSeverity: protocol.SeverityError}
+					for _, summary := range collectWorkspaceDiagnostics(lspClient.WorkspaceDiagnostics(), errorsOnly, false).Files {
+						if dependentDirs[filepath.Dir(protocol.URIToPath(summary.URI))] {
+							result.DependentErrors = append(result.DependentErrors, summary)
+						}
+					}
+				}
+			}
+		}
+
+		data, err := json.Marshal(result)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal result: %w", err)
+		}
+
+		return mcp.NewToolResultText(string(data)), nil
+	})
+}
+
+// touchesChangedLines reports whether a range overlaps the changed lines of a
+// file
+func touchesChangedLines(file gitcmd.ChangedFile, rng protocol.Range) bool {
+	for line := rng.Start.Line; line <= rng.End.Line; line++ {
+		if file.Contains(line + 1) {
+			return true
+		}
+	}
+	return false
+}
+
+// isUnder reports whether path is dir or inside it
+func isUnder(path, dir string) bool {
+	return path == dir || strings.HasPrefix(path, dir+string(filepath.Separator))
+}
@@ -0,0 +1,289 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/solatis/mcp-gopls/pkg/lsp/client"
+	"github.com/solatis/mcp-gopls/pkg/lsp/protocol"
+)
+
+const (
+	deadCodeUnused   = "unused"
+	deadCodeTestOnly = "test_only"
+
+	// defaultMaxDeadCodeSymbols bounds the number of symbols whose
+	// references are looked up in a single dead_code call
+	defaultMaxDeadCodeSymbols = 1000
+)
+
+// deadCodeAnalyzers are the gopls analyzers reporting unused code
+var deadCodeAnalyzers = map[string]bool{
+	"unusedfunc":   true,
+	"unusedparams": true,
+}
+
+// deadSymbol is a symbol without references outside its own declaration, or
+// only referenced by tests
+type deadSymbol struct {
+	Name           string `json:"name"`
+	Kind           string `json:"kind"`
+	File           string `json:"file"`
+	Line           int    `json:"line"`
+	Status         string `json:"status"`
+	TestReferences int    `json:"test_references,omitempty"`
+	// Analyzer is set when a gopls analyzer reported the symbol as well
+	Analyzer string `json:"analyzer,omitempty"`
+}
+
+type analyzerFinding struct {
+	File    string `json:"file"`
+	Line    int    `json:"line"`
+	Source  string `json:"source"`
+	Message string `json:"message"`
+}
+
+type deadCodeResult struct {
+	Symbols          []deadSymbol      `json:"symbols"`
+	AnalyzerFindings []analyzerFinding `json:"analyzer_findings"`
+	Checked          int               `json:"checked"`
+	Truncated        bool              `json:"truncated,omitempty"`
+}
+
+// deadCodeCandidate reports whether a top-level symbol is worth checking:
+// functions, methods and types, plus exported constants and variables.
+// Entry points and test functions are left out.
+func deadCodeCandidate(symbol protocol.DocumentSymbol) bool {
+	switch symbol.Kind {
+	case protocol.SKFunction:
+		return symbol.Name != "main" && symbol.Name != "init"
+	case protocol.SKMethod:
+		return true
+	case protocol.SKConstant, protocol.SKVariable:
+		return symbol.Name != "_" && isExportedSymbol(symbol.Name)
+	default:
+		return typeSymbolKinds[symbol.Kind]
+	}
+}
+
+// packageDirs returns dir, and with recursive set the directories below it,
+// that contain Go files
+func packageDirs(dir string, recursive bool) ([]string, error) {
+	if !recursive {
+		return []string{dir}, nil
+	}
+
+	var dirs []string
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !entry.IsDir() {
+			return nil
+		}
+		name := entry.Name()
+		if path != dir && (name == "vendor" || name == "testdata" || strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_")) {
+			return filepath.SkipDir
+		}
+		if packageGoFile(path) != "" {
+			dirs = append(dirs, path)
+		}
+		return nil
+	})
+	return dirs, err
+}
+
+func (t *LSPTools) registerDeadCode(s *server.MCPServer) {
+	deadCodeTool := mcp.NewTool("dead_code",
+		mcp.WithDescription("DEAD CODE REPORT: Use this tool to find code that can be deleted. Lists the functions, methods, types and exported constants and variables of a package (or of every package below a directory) that have no references outside their own declaration, and those only referenced from tests, combined with the findings of the gopls unusedfunc and unusedparams analyzers. Methods implementing an interface are left out, since they may be called dynamically. Exported symbols may still be used by other modules, or through reflection."),
+		mcp.WithString("dir",
+			mcp.Required(),
+			mcp.Description("Package directory to check, or the module root with recursive, as a path or file:// URI"),
+		),
+		mcp.WithBoolean("recursive",
+			mcp.Description("Also check the packages in subdirectories (default false)"),
+		),
+		mcp.WithBoolean("include_test_only",
+			mcp.Description("Also report symbols only referenced from tests (default true)"),
+		),
+		mcp.WithBoolean("include_interface_methods",
+			mcp.Description("Also report unreferenced methods that implement an interface (default false)"),
+		),
+		mcp.WithNumber("max_symbols",
+			mcp.Description(fmt.Sprintf("Maximum number of symbols to look up references for (default %d)", defaultMaxDeadCodeSymbols)),
+			mcp.Min(1),
+		),
+	)
+
+	s.AddTool(deadCodeTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		dir := request.GetString("dir", "")
+		if dir == "" {
+			return nil, errors.New("dir is required")
+		}
+		dir = resolveDir(dir)
+		includeTestOnly := request.GetBool("include_test_only", true)
+		includeInterfaceMethods := request.GetBool("include_interface_methods", false)
+		maxSymbols := request.GetInt("max_symbols", defaultMaxDeadCodeSymbols)
+
+		lspClient := t.getClient()
+		if lspClient == nil {
+			return nil, errors.New("LSP client not available")
+		}
+
+		dirs, err := packageDirs(dir, request.GetBool("recursive", false))
+		if err != nil {
+			return nil, fmt.Errorf("failed to list package directories: %w", err)
+		}
+
+		result := deadCodeResult{
+			Symbols:          []deadSymbol{},
+			AnalyzerFindings: []analyzerFinding{},
+		}
+		cache := newSymbolCache(lspClient)
+
+		// gopls runs the analyzers on every workspace package, so their
+		// findings are read from the published diagnostics instead of
+		// opening each file
+		lspClient.WaitForDiagnosticsIdle(diagnosticsQuietPeriod, workspaceDiagnosticsTimeout)
+		published := lspClient.WorkspaceDiagnostics()
+
+	scan:
+		for _, pkgDir := range dirs {
+			entries, err := os.ReadDir(pkgDir)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read package directory: %w", err)
+			}
+
+			for _, entry := range entries {
+				name := entry.Name()
+				if entry.IsDir() || !strings.HasSuffix(name, ".go") || strings.HasSuffix(name, "_test.go") {
+					continue
+				}
+				path := filepath.Join(pkgDir, name)
+				uri := convertPathToURI(path)
+
+				flagged := make(map[int]string)
+				for _, d := range published[uri] {
+					if deadCodeAnalyzers[d.Source] {
+						result.AnalyzerFindings = append(result.AnalyzerFindings, analyzerFinding{
+							File:    path,
+							Line:    d.Range.Start.Line,
+							Source:  d.Source,
+							Message: d.Message,
+						})
+						flagged[d.Range.Start.Line] = d.Source
+					}
+				}
+
+				symbols, err := cache.get(uri)
+				if err != nil {
+					return nil, t.handleLSPError(err)
+				}
+
+				for _, symbol := range symbols {
+					if !deadCodeCandidate(symbol) {
+						continue
+					}
+					if result.Checked >= maxSymbols {
+						result.Truncated = true
+						break scan
+					}
+					result.Checked++
+
+					status, testRefs, err := symbolUsage(lspClient, cache, uri, symbol)
+					if err != nil {
+						return nil, t.handleLSPError(err)
+					}
+					if status == "" || (status == deadCodeTestOnly && !includeTestOnly) {
+						continue
+					}
+
+					if symbol.Kind == protocol.SKMethod && !includeInterfaceMethods {
+						start := symbol.SelectionRange.Start
+						implemented, err := lspClient.GetImplementations(uri, start.Line, start.Character)
+						if err != nil {
+							return nil, t.handleLSPError(err)
+						}
+						if len(implemented) > 0 {
+							continue
+						}
+					}
+
+					result.Symbols = append(result.Symbols, deadSymbol{
+						Name:           symbol.Name,
+						Kind:           symbol.Kind.String(),
+						File:           path,
+						Line:           symbol.SelectionRange.Start.Line,
+						Status:         status,
+						TestReferences: testRefs,
+						Analyzer:       flagged[symbol.SelectionRange.Start.Line],
+					})
+				}
+			}
+		}
+
+		data, err := json.Marshal(result)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal result: %w", err)
+		}
+
+		return mcp.NewToolResultText(string(data)), nil
+	})
+}
+
+// symbolUsage classifies the references to a symbol: "unused" without any
+// outside its own declaration, "test_only" when only tests refer to it, and
+// "" otherwise. The number of references from tests is returned as well.
+func symbolUsage(lspClient client.LSPClient, cache *symbolCache, uri string, symbol protocol.DocumentSymbol) (string, int, error) {
+	start := symbol.SelectionRange.Start
+	references, err := lspClient.FindReferences(uri, start.Line, start.Character, false)
+	if err != nil {
+		return "", 0, err
+	}
+
+	testRefs := 0
+	for _, ref := range references {
+		// References from within the declaration, such as recursive calls
+		// or methods of a type, do not keep it alive
+		if ref.URI == uri && rangeContains(symbol.Range, ref.Range.Start) {
+			continue
+		}
+		// Nor do the receivers and bodies of the methods of a type
+		if typeSymbolKinds[symbol.Kind] {
+			enclosing, err := cache.enclosing(ref.URI, ref.Range.Start)
+			if err != nil {
+				return "", 0, err
+			}
+			if enclosing != nil && enclosing.Kind == protocol.SKMethod && isMethodOf(enclosing.Name, symbol.Name) {
+				continue
+			}
+		}
+		if !strings.HasSuffix(ref.URI, "_test.go") {
+			return "", testRefs, nil
+		}
+		testRefs++
+	}
+
+	if testRefs > 0 {
+		return deadCodeTestOnly, testRefs, nil
+	}
+	return deadCodeUnused, 0, nil
+}
+
+// isMethodOf reports whether a method symbol name, such as "(*Server).Start",
+// belongs to the named type
+func isMethodOf(methodName, typeName string) bool {
+	return strings.HasPrefix(methodName, "("+typeName+").") ||
+		strings.HasPrefix(methodName, "(*"+typeName+").") ||
+		strings.HasPrefix(methodName, "("+typeName+"[") ||
+		strings.HasPrefix(methodName, "(*"+typeName+"[")
+}
@@ -0,0 +1,116 @@
+package tools
+
+import (
+	"testing"
+
+	"github.com/solatis/mcp-gopls/pkg/lsp/protocol"
+)
+
+// fakeReferenceClient also answers reference requests with a fixed list
+type fakeReferenceClient struct {
+	fakeSymbolClient
+	references []protocol.Location
+}
+
+func (c *fakeReferenceClient) FindReferences(uri string, line, character int, includeDeclaration bool) ([]protocol.Location, error) {
+	return c.references, nil
+}
+
+func TestDeadCodeCandidate(t *testing.T) {
+	tests := []struct {
+		name string
+		kind protocol.SymbolKind
+		want bool
+	}{
+		{"helper", protocol.SKFunction, true},
+		{"main", protocol.SKFunction, false},
+		{"init", protocol.SKFunction, false},
+		{"(*Server).start", protocol.SKMethod, true},
+		{"Server", protocol.SKStruct, true},
+		{"Handler", protocol.SKInterface, true},
+		{"MaxSize", protocol.SKConstant, true},
+		{"maxSize", protocol.SKConstant, false},
+		{"_", protocol.SKVariable, false},
+		{"Name", protocol.SKField, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := deadCodeCandidate(protocol.DocumentSymbol{Name: tt.name, Kind: tt.kind}); got != tt.want {
+				t.Errorf("deadCodeCandidate(%s %s) = %v, want %v", tt.kind, tt.name, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSymbolUsage(t *testing.T) {
+	const (
+		uri     = "file:///src/app/server.go"
+		testURI = "file:///src/app/server_test.go"
+		mainURI = "file:///src/app/main.go"
+	)
+	lines := func(start, end int) protocol.Range {
+		return protocol.Range{Start: protocol.Position{Line: start}, End: protocol.Position{Line: end, Character: 1}}
+	}
+	at := func(uri string, line int) protocol.Location {
+		return protocol.Location{URI: uri, Range: lines(line, line)}
+	}
+
+	server := protocol.DocumentSymbol{Name: "Server", Kind: protocol.SKStruct, Range: lines(2, 4), SelectionRange: lines(2, 2)}
+	documents := map[string][]protocol.DocumentSymbol{
+		uri: {
+			server,
+			{Name: "(*Server).Start", Kind: protocol.SKMethod, Range: lines(6, 8)},
+			{Name: "(*Other).Start", Kind: protocol.SKMethod, Range: lines(10, 12)},
+		},
+	}
+
+	tests := []struct {
+		name         string
+		references   []protocol.Location
+		want         string
+		wantTestRefs int
+	}{
+		{"no references", nil, deadCodeUnused, 0},
+		{"within its declaration", []protocol.Location{at(uri, 3)}, deadCodeUnused, 0},
+		{"from its own methods", []protocol.Location{at(uri, 6), at(uri, 7)}, deadCodeUnused, 0},
+		{"from another type's method", []protocol.Location{at(uri, 11)}, "", 0},
+		{"from tests only", []protocol.Location{at(testURI, 5), at(testURI, 9)}, deadCodeTestOnly, 2},
+		{"from tests and code", []protocol.Location{at(testURI, 5), at(mainURI, 3)}, "", 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lspClient := &fakeReferenceClient{
+				fakeSymbolClient: fakeSymbolClient{documents: documents},
+				references:       tt.references,
+			}
+			status, testRefs, err := symbolUsage(lspClient, newSymbolCache(lspClient), uri, server)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if status != tt.want || testRefs != tt.wantTestRefs {
+				t.Errorf("symbolUsage() = %q, %d, want %q, %d", status, testRefs, tt.want, tt.wantTestRefs)
+			}
+		})
+	}
+}
+
+func TestIsMethodOf(t *testing.T) {
+	tests := []struct {
+		method string
+		want   bool
+	}{
+		{"(Server).Start", true},
+		{"(*Server).Start", true},
+		{"(*Server[T]).Start", true},
+		{"(*ServerPool).Start", false},
+		{"Server", false},
+	}
+
+	for _, tt := range tests {
+		if got := isMethodOf(tt.method, "Server"); got != tt.want {
+			t.Errorf("isMethodOf(%q, Server) = %v, want %v", tt.method, got, tt.want)
+		}
+	}
+}
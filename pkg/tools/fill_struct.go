@@ -0,0 +1,404 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/solatis/mcp-gopls/pkg/diff"
+	"github.com/solatis/mcp-gopls/pkg/lsp/client"
+	"github.com/solatis/mcp-gopls/pkg/lsp/protocol"
+)
+
+// maxFillDepth bounds recursive filling of nested struct literals
+const maxFillDepth = 5
+
+type fillStructResult struct {
+	Changed     bool                  `json:"changed"`
+	Literal     string                `json:"literal"`
+	Diff        string                `json:"diff,omitempty"`
+	Diagnostics []protocol.Diagnostic `json:"diagnostics"`
+}
+
+// compositeLitAt returns the innermost composite literal containing offset
+func compositeLitAt(fset *token.FileSet, file *ast.File, offset int) *ast.CompositeLit {
+	var found *ast.CompositeLit
+	ast.Inspect(file, func(n ast.Node) bool {
+		if n == nil {
+			return false
+		}
+		if offset < fset.Position(n.Pos()).Offset || offset > fset.Position(n.End()).Offset {
+			return false
+		}
+		if lit, ok := n.(*ast.CompositeLit); ok {
+			found = lit
+		}
+		return true
+	})
+	return found
+}
+
+// compositeLitEndingAt returns the composite literal whose closing brace is
+// at offset
+func compositeLitEndingAt(fset *token.FileSet, file *ast.File, offset int) *ast.CompositeLit {
+	var found *ast.CompositeLit
+	ast.Inspect(file, func(n ast.Node) bool {
+		if lit, ok := n.(*ast.CompositeLit); ok && fset.Position(lit.Rbrace).Offset == offset {
+			found = lit
+			return false
+		}
+		return found == nil
+	})
+	return found
+}
+
+// nestedLiterals returns the composite literals given as element values of
+// lit, directly or behind "&", at the given depth below lit
+func nestedLiterals(lit *ast.CompositeLit, depth int) []*ast.CompositeLit {
+	var children []*ast.CompositeLit
+	for _, elt := range lit.Elts {
+		if kv, ok := elt.(*ast.KeyValueExpr); ok {
+			elt = kv.Value
+		}
+		if unary, ok := elt.(*ast.UnaryExpr); ok && unary.Op == token.AND {
+			elt = unary.X
+		}
+		if child, ok := elt.(*ast.CompositeLit); ok {
+			children = append(children, child)
+		}
+	}
+
+	if depth == 1 {
+		return children
+	}
+	var nested []*ast.CompositeLit
+	for _, child := range children {
+		nested = append(nested, nestedLiterals(child, depth-1)...)
+	}
+	return nested
+}
+
+func (t *LSPTools) registerFillStruct(s *server.MCPServer) {
+	fillStructTool := mcp.NewTool("fill_struct",
+		mcp.WithDescription("FILL A STRUCT LITERAL: Use this tool instead of typing out every field of a struct literal. Fills a composite literal such as 'Config{}' with all its fields through the gopls fill-struct refactoring, using variables in scope when their names and types match, zero values otherwise. Can recurse into nested struct fields and mark every filled field with a TODO comment. Applies the change and returns the filled literal and its diff."),
+		mcp.WithString("file_uri",
+			mcp.Required(),
+			mcp.Description("URI or absolute path of the Go file"),
+		),
+		mcp.WithObject("position",
+			mcp.Required(),
+			mcp.Description("Position within the composite literal. Must contain 'line' (0-indexed line number) and 'character' (0-indexed column number) keys"),
+		),
+		mcp.WithBoolean("recursive",
+			mcp.Description("Also fill the empty struct literals of nested fields (default false)"),
+		),
+		mcp.WithBoolean("placeholders",
+			mcp.Description("Mark every filled field with a '// TODO: set <Field>' comment, to replace the zero values (default false)"),
+		),
+	)
+
+	s.AddTool(fillStructTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		fileURI := request.GetString("file_uri", "")
+		if fileURI == "" {
+			return nil, errors.New("file_uri is required")
+		}
+		if !strings.HasPrefix(fileURI, "file://") {
+			fileURI = convertPathToURI(fileURI)
+		}
+		pos, err := parsePosition(request.GetArguments()["position"])
+		if err != nil {
+			return nil, err
+		}
+
+		lspClient := t.getClient()
+		if lspClient == nil {
+			return nil, errors.New("LSP client not available")
+		}
+
+		path := protocol.URIToPath(fileURI)
+		original, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read file: %w", err)
+		}
+		if err := lspClient.DidOpen(fileURI, "go", string(original)); err != nil {
+			return nil, t.handleLSPError(err)
+		}
+
+		offset, err := protocol.PositionToOffset(string(original), pos)
+		if err != nil {
+			return nil, err
+		}
+
+		filler := structFiller{client: lspClient, uri: fileURI, path: path}
+		content, lit, err := filler.fill(string(original), offset, request.GetBool("recursive", false), request.GetBool("placeholders", false))
+		if err != nil {
+			return nil, t.handleLSPError(err)
+		}
+
+		diagnostics, err := lspClient.GetDiagnostics(fileURI)
+		if err != nil {
+			return nil, t.handleLSPError(err)
+		}
+		if diagnostics == nil {
+			diagnostics = []protocol.Diagnostic{}
+		}
+
+		name := filepath.Base(path)
+		result := fillStructResult{
+			Changed:     content != string(original),
+			Literal:     lit,
+			Diff:        diff.Unified("a/"+name, "b/"+name, string(original), content),
+			Diagnostics: diagnostics,
+		}
+
+		data, err := json.Marshal(result)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal result: %w", err)
+		}
+
+		return mcp.NewToolResultText(string(data)), nil
+	})
+}
+
+// structFiller applies gopls fill-struct actions to a file on disk
+type structFiller struct {
+	client client.LSPClient
+	uri    string
+	path   string
+}
+
+// fill fills the composite literal containing offset and returns the new
+// content of the file with the text of the filled literal. The literal is
+// found again after each edit through the text following it, which gopls
+// leaves alone; added imports only change the text before it.
+func (f structFiller) fill(content string, offset int, recursive, placeholders bool) (string, string, error) {
+	fset, file, err := parseGoFile(content)
+	if err != nil {
+		return "", "", err
+	}
+	lit := compositeLitAt(fset, file, offset)
+	if lit == nil {
+		return "", "", errors.New("no composite literal at the position")
+	}
+	originalKeys := literalKeys(lit)
+	tail := len(content) - fset.Position(lit.Rbrace).Offset
+
+	content, filled, err := f.fillAt(content, offset)
+	if err != nil {
+		return "", "", err
+	}
+	if !filled {
+		return "", "", errors.New("gopls cannot fill this literal: it is not a struct literal or has no fields to add")
+	}
+
+	relocate := func(content string) (*token.FileSet, *ast.CompositeLit, error) {
+		fset, file, err := parseGoFile(content)
+		if err != nil {
+			return nil, nil, err
+		}
+		lit := compositeLitEndingAt(fset, file, len(content)-tail)
+		if lit == nil {
+			return nil, nil, errors.New("lost track of the literal after filling it")
+		}
+		return fset, lit, nil
+	}
+
+	if recursive {
+		for depth := 1; depth <= maxFillDepth; depth++ {
+			fset, lit, err := relocate(content)
+			if err != nil {
+				return "", "", err
+			}
+
+			nested := nestedLiterals(lit, depth)
+			if len(nested) == 0 {
+				break
+			}
+
+			var empty []int
+			for _, n := range nested {
+				if len(n.Elts) == 0 {
+					empty = append(empty, fset.Position(n.Rbrace).Offset)
+				}
+			}
+
+			// Fill from the end, so that the offsets before stay valid
+			sort.Sort(sort.Reverse(sort.IntSlice(empty)))
+			for _, rbrace := range empty {
+				// Imports added by a fill shift the literals before it
+				updated, _, err := f.fillAt(content, rbrace)
+				if err != nil {
+					return "", "", err
+				}
+				if shift := importShift(content, updated); shift != 0 {
+					for i := range empty {
+						empty[i] += shift
+					}
+				}
+				content = updated
+			}
+		}
+	}
+
+	if placeholders {
+		fset, lit, err := relocate(content)
+		if err != nil {
+			return "", "", err
+		}
+		content, err = f.markPlaceholders(content, fset, lit, originalKeys)
+		if err != nil {
+			return "", "", err
+		}
+	}
+
+	fset, lit, err = relocate(content)
+	if err != nil {
+		return "", "", err
+	}
+	literal := content[fset.Position(lit.Pos()).Offset:fset.Position(lit.End()).Offset]
+	return content, literal, nil
+}
+
+// fillAt applies the fill-struct action for the literal at offset, reporting
+// whether gopls offered one
+func (f structFiller) fillAt(content string, offset int) (string, bool, error) {
+	pos := protocol.OffsetToPosition(content, offset)
+	actions, err := f.client.CodeActions(f.uri, protocol.Range{Start: pos, End: pos}, "refactor.rewrite")
+	if err != nil {
+		return "", false, err
+	}
+
+	var fill *protocol.CodeAction
+	for i, action := range actions {
+		// gopls before v0.17 reports it as "refactor.rewrite" titled "Fill T"
+		if action.Disabled == nil && (action.Kind == "refactor.rewrite.fillStruct" || strings.HasPrefix(action.Title, "Fill ")) {
+			fill = &actions[i]
+			break
+		}
+	}
+	if fill == nil {
+		return content, false, nil
+	}
+
+	if err := f.client.ApplyCodeAction(*fill); err != nil {
+		return "", false, err
+	}
+	updated, err := os.ReadFile(f.path)
+	if err != nil {
+		return "", false, fmt.Errorf("failed to read file: %w", err)
+	}
+	return string(updated), true, nil
+}
+
+// markPlaceholders appends a TODO comment to the lines of the fields gopls
+// filled with a value other than a nested literal
+func (f structFiller) markPlaceholders(content string, fset *token.FileSet, lit *ast.CompositeLit, originalKeys map[string]bool) (string, error) {
+	var offsets []int
+	comments := make(map[int]string)
+
+	var visit func(lit *ast.CompositeLit, keep map[string]bool)
+	visit = func(lit *ast.CompositeLit, keep map[string]bool) {
+		for _, elt := range lit.Elts {
+			kv, ok := elt.(*ast.KeyValueExpr)
+			if !ok {
+				continue
+			}
+			key, ok := kv.Key.(*ast.Ident)
+			if !ok || keep[key.Name] {
+				continue
+			}
+
+			value := kv.Value
+			if unary, ok := value.(*ast.UnaryExpr); ok && unary.Op == token.AND {
+				value = unary.X
+			}
+			if nested, ok := value.(*ast.CompositeLit); ok && len(nested.Elts) > 0 {
+				visit(nested, nil)
+				continue
+			}
+
+			end := fset.Position(kv.End()).Offset
+			lineEnd := strings.IndexByte(content[end:], '\n')
+			if lineEnd < 0 {
+				continue
+			}
+			lineEnd += end
+			if strings.Contains(content[end:lineEnd], "//") {
+				continue
+			}
+			offsets = append(offsets, lineEnd)
+			comments[lineEnd] = " // TODO: set " + key.Name
+		}
+	}
+	visit(lit, originalKeys)
+
+	sort.Sort(sort.Reverse(sort.IntSlice(offsets)))
+	for _, offset := range offsets {
+		content = content[:offset] + comments[offset] + content[offset:]
+	}
+
+	if err := os.WriteFile(f.path, []byte(content), 0o644); err != nil {
+		return "", fmt.Errorf("failed to write %s: %w", f.path, err)
+	}
+	if err := f.client.DidOpen(f.uri, "go", content); err != nil {
+		return "", err
+	}
+	return content, nil
+}
+
+// literalKeys returns the field names already set in a keyed literal
+func literalKeys(lit *ast.CompositeLit) map[string]bool {
+	keys := make(map[string]bool)
+	for _, elt := range lit.Elts {
+		if kv, ok := elt.(*ast.KeyValueExpr); ok {
+			if key, ok := kv.Key.(*ast.Ident); ok {
+				keys[key.Name] = true
+			}
+		}
+	}
+	return keys
+}
+
+// importShift returns how many bytes an edit added to the import section of
+// a file, i.e. before its first declaration other than imports
+func importShift(before, after string) int {
+	declStart := func(content string) int {
+		fset, file, err := parseGoFile(content)
+		if err != nil {
+			return -1
+		}
+		for _, decl := range file.Decls {
+			if gen, ok := decl.(*ast.GenDecl); ok && gen.Tok == token.IMPORT {
+				continue
+			}
+			return fset.Position(decl.Pos()).Offset
+		}
+		return -1
+	}
+
+	b, a := declStart(before), declStart(after)
+	if b < 0 || a < 0 {
+		return 0
+	}
+	return a - b
+}
+
+func parseGoFile(content string) (*token.FileSet, *ast.File, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", content, parser.ParseComments)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse file: %w", err)
+	}
+	return fset, file, nil
+}
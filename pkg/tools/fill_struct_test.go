@@ -0,0 +1,285 @@
+package tools
+
+import (
+	"go/ast"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/solatis/mcp-gopls/pkg/lsp/client"
+	"github.com/solatis/mcp-gopls/pkg/lsp/protocol"
+)
+
+// fillField is a field gopls fills in, with the package its value needs
+type fillField struct {
+	name, value, importPath string
+}
+
+// fakeFillClient mimics the gopls fill-struct refactoring on a file on disk:
+// it offers a fill action for empty literals of known struct types, which
+// sets every field and adds the imports the values need above the literal.
+type fakeFillClient struct {
+	client.LSPClient
+	path    string
+	structs map[string][]fillField
+}
+
+func (c *fakeFillClient) CodeActions(uri string, rng protocol.Range, only ...string) ([]protocol.CodeAction, error) {
+	data, err := os.ReadFile(c.path)
+	if err != nil {
+		return nil, err
+	}
+	content := string(data)
+
+	offset, err := protocol.PositionToOffset(content, rng.Start)
+	if err != nil {
+		return nil, err
+	}
+	fset, file, err := parseGoFile(content)
+	if err != nil {
+		return nil, err
+	}
+	lit := compositeLitAt(fset, file, offset)
+	if lit == nil || len(lit.Elts) > 0 {
+		return nil, nil
+	}
+	typeName, ok := lit.Type.(*ast.Ident)
+	if !ok {
+		return nil, nil
+	}
+	fields, ok := c.structs[typeName.Name]
+	if !ok {
+		return nil, nil
+	}
+
+	start := fset.Position(lit.Pos()).Offset
+	lineStart := strings.LastIndexByte(content[:start], '\n') + 1
+	indent := content[lineStart : lineStart+len(content[lineStart:])-len(strings.TrimLeft(content[lineStart:], "\t"))]
+
+	var b strings.Builder
+	b.WriteString(typeName.Name + "{\n")
+	var imports []string
+	for _, field := range fields {
+		b.WriteString(indent + "\t" + field.name + ": " + field.value + ",\n")
+		if field.importPath != "" && !strings.Contains(content, "\""+field.importPath+"\"") {
+			imports = append(imports, field.importPath)
+		}
+	}
+	b.WriteString(indent + "}")
+
+	updated := content[:start] + b.String() + content[fset.Position(lit.End()).Offset:]
+	for _, importPath := range imports {
+		updated = strings.Replace(updated, "package app\n", "package app\n\nimport \""+importPath+"\"\n", 1)
+	}
+
+	return []protocol.CodeAction{{
+		Title: "Fill " + typeName.Name,
+		Kind:  "refactor.rewrite.fillStruct",
+		Edit: &protocol.WorkspaceEdit{
+			Changes: map[string][]protocol.TextEdit{
+				uri: {{
+					Range:   protocol.Range{End: protocol.OffsetToPosition(content, len(content))},
+					NewText: updated,
+				}},
+			},
+		},
+	}}, nil
+}
+
+func (c *fakeFillClient) ApplyCodeAction(action protocol.CodeAction) error {
+	for _, edits := range action.Edit.Changes {
+		data, err := os.ReadFile(c.path)
+		if err != nil {
+			return err
+		}
+		updated, err := protocol.ApplyTextEdits(string(data), edits)
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(c.path, []byte(updated), 0o644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *fakeFillClient) DidOpen(uri, languageID, text string) error {
+	return nil
+}
+
+const fillSource = `package app
+
+type Limits struct {
+	Max int
+}
+
+type Server struct {
+	Addr    string
+	Limits  *Limits
+	Started time.Time
+}
+
+type Config struct {
+	Name   string
+	Server Server
+	Backup *Server
+	Tags   []string
+}
+
+func newConfig() Config {
+	return Config{}
+}
+`
+
+func newFakeFiller(t *testing.T) (structFiller, string) {
+	path := filepath.Join(t.TempDir(), "app.go")
+	if err := os.WriteFile(path, []byte(fillSource), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	fake := &fakeFillClient{
+		path: path,
+		structs: map[string][]fillField{
+			"Config": {
+				{name: "Name", value: `""`},
+				{name: "Server", value: "Server{}"},
+				{name: "Backup", value: "&Server{}"},
+				{name: "Tags", value: "nil"},
+			},
+			"Server": {
+				{name: "Addr", value: `""`},
+				{name: "Limits", value: "&Limits{}"},
+				{name: "Started", value: "time.Time{}", importPath: "time"},
+			},
+			"Limits": {
+				{name: "Max", value: "0"},
+			},
+		},
+	}
+	return structFiller{client: fake, uri: "file://" + path, path: path}, path
+}
+
+func TestStructFillerFill(t *testing.T) {
+	literalOffset := strings.Index(fillSource, "Config{}")
+
+	tests := []struct {
+		name         string
+		recursive    bool
+		placeholders bool
+		want         string
+	}{
+		{
+			name: "single level",
+			want: `Config{
+		Name: "",
+		Server: Server{},
+		Backup: &Server{},
+		Tags: nil,
+	}`,
+		},
+		{
+			name:      "nested, pointer and slice fields with an added import",
+			recursive: true,
+			want: `Config{
+		Name: "",
+		Server: Server{
+			Addr: "",
+			Limits: &Limits{
+				Max: 0,
+			},
+			Started: time.Time{},
+		},
+		Backup: &Server{
+			Addr: "",
+			Limits: &Limits{
+				Max: 0,
+			},
+			Started: time.Time{},
+		},
+		Tags: nil,
+	}`,
+		},
+		{
+			name:         "placeholders",
+			placeholders: true,
+			want: `Config{
+		Name: "", // TODO: set Name
+		Server: Server{}, // TODO: set Server
+		Backup: &Server{}, // TODO: set Backup
+		Tags: nil, // TODO: set Tags
+	}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filler, path := newFakeFiller(t)
+
+			content, literal, err := filler.fill(fillSource, literalOffset, tt.recursive, tt.placeholders)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if literal != tt.want {
+				t.Errorf("literal:\n%s\nwant:\n%s", literal, tt.want)
+			}
+
+			onDisk, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(onDisk) != content {
+				t.Errorf("returned content differs from the file on disk")
+			}
+			if !strings.HasSuffix(content, "\treturn "+tt.want+"\n}\n") {
+				t.Errorf("literal not in place:\n%s", content)
+			}
+			if wantImport := tt.recursive; strings.Contains(content, `import "time"`) != wantImport {
+				t.Errorf("time import added = %v, want %v", !wantImport, wantImport)
+			}
+		})
+	}
+}
+
+func TestStructFillerNothingToFill(t *testing.T) {
+	filler, _ := newFakeFiller(t)
+
+	// The cursor is on "return", outside of any literal
+	offset := strings.Index(fillSource, "return")
+	if _, _, err := filler.fill(fillSource, offset, false, false); err == nil {
+		t.Error("fill succeeded without a composite literal")
+	}
+}
+
+func TestImportShift(t *testing.T) {
+	before := "package app\n\nfunc f() {}\n"
+	after := "package app\n\nimport \"time\"\n\nfunc f() {}\n"
+	if got, want := importShift(before, after), len("import \"time\"\n\n"); got != want {
+		t.Errorf("importShift = %d, want %d", got, want)
+	}
+	if got := importShift(before, before); got != 0 {
+		t.Errorf("importShift without changes = %d, want 0", got)
+	}
+}
+
+func TestNestedLiterals(t *testing.T) {
+	src := "package app\n\nvar c = Config{Server: Server{Limits: &Limits{}}, Backup: &Server{}, Tags: nil}\n"
+	fset, file, err := parseGoFile(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lit := compositeLitAt(fset, file, strings.Index(src, "Config{")+1)
+	if lit == nil {
+		t.Fatal("no literal found")
+	}
+
+	if n := len(nestedLiterals(lit, 1)); n != 2 {
+		t.Errorf("depth 1: %d literals, want 2", n)
+	}
+	if n := len(nestedLiterals(lit, 2)); n != 1 {
+		t.Errorf("depth 2: %d literals, want 1", n)
+	}
+	if keys := literalKeys(lit); len(keys) != 3 || !keys["Server"] || !keys["Backup"] || !keys["Tags"] {
+		t.Errorf("literalKeys = %v", keys)
+	}
+}
@@ -0,0 +1,200 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/solatis/mcp-gopls/pkg/diff"
+	"github.com/solatis/mcp-gopls/pkg/lsp/client"
+	"github.com/solatis/mcp-gopls/pkg/lsp/protocol"
+)
+
+// goModResult reports the effect of a go.mod command
+type goModResult struct {
+	Command      string `json:"command"`
+	Changed      bool   `json:"changed"`
+	Diff         string `json:"diff,omitempty"`
+	GoSumChanged bool   `json:"go_sum_changed"`
+}
+
+func (t *LSPTools) registerGoModTools(s *server.MCPServer) {
+	goModURI := mcp.WithString("go_mod_uri",
+		mcp.Required(),
+		mcp.Description("URI or absolute path of the go.mod file, or of the module directory"),
+	)
+	offline := mcp.WithBoolean("offline",
+		mcp.Description("Only use modules already in the module cache (GOPROXY=off), for use without network access"),
+	)
+
+	tidyTool := mcp.NewTool("go_mod_tidy",
+		mcp.WithDescription("SAFE GO.MOD CLEANUP: Use this tool instead of editing go.mod by hand to add missing and remove unused requirements, exactly like 'go mod tidy'. Use this when: 1) After adding or removing imports, 2) gopls reports go.mod related diagnostics, 3) Before committing dependency changes. Returns the resulting go.mod diff."),
+		goModURI,
+		offline,
+	)
+	s.AddTool(tidyTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return t.runGoModCommand(ctx, request, "gopls.tidy", func(uri string) any {
+			return map[string]any{"URIs": []string{uri}}
+		}, "mod", "tidy")
+	})
+
+	addTool := mcp.NewTool("go_mod_add_dependency",
+		mcp.WithDescription("SAFE DEPENDENCY ADDITION: Use this tool instead of editing go.mod by hand to add a module requirement or change its version, like 'go get module@version'. Use this when: 1) Code needs a new third-party package, 2) A dependency must be upgraded or downgraded. Returns the resulting go.mod diff."),
+		goModURI,
+		mcp.WithString("module",
+			mcp.Required(),
+			mcp.Description("Module path to add, e.g. 'golang.org/x/sync'"),
+		),
+		mcp.WithString("version",
+			mcp.Description("Version to require, e.g. 'v0.7.0' or 'latest' (default 'latest')"),
+		),
+		offline,
+	)
+	s.AddTool(addTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		module := request.GetString("module", "")
+		if module == "" {
+			return nil, errors.New("module is required")
+		}
+		version := request.GetString("version", "latest")
+
+		return t.runGoModCommand(ctx, request, "gopls.add_dependency", func(uri string) any {
+			return map[string]any{
+				"URI":        uri,
+				"GoCmdArgs":  []string{module + "@" + version},
+				"AddRequire": true,
+			}
+		}, "get", module+"@"+version)
+	})
+
+	removeTool := mcp.NewTool("go_mod_remove_dependency",
+		mcp.WithDescription("SAFE DEPENDENCY REMOVAL: Use this tool instead of editing go.mod by hand to remove a module requirement that is no longer needed. Returns the resulting go.mod diff."),
+		goModURI,
+		mcp.WithString("module",
+			mcp.Required(),
+			mcp.Description("Module path to remove, e.g. 'github.com/pkg/errors'"),
+		),
+		offline,
+	)
+	s.AddTool(removeTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		module := request.GetString("module", "")
+		if module == "" {
+			return nil, errors.New("module is required")
+		}
+
+		return t.runGoModCommand(ctx, request, "gopls.remove_dependency", func(uri string) any {
+			return map[string]any{
+				"URI":            uri,
+				"ModulePath":     module,
+				"OnlyDiagnostic": false,
+			}
+		}, "get", module+"@none")
+	})
+
+	vendorTool := mcp.NewTool("go_mod_vendor",
+		mcp.WithDescription("VENDOR DEPENDENCIES: Use this tool to refresh the vendor directory of a module, like 'go mod vendor'. Use this when the module uses vendoring and its requirements changed. Returns the resulting go.mod diff, if any."),
+		goModURI,
+		offline,
+	)
+	s.AddTool(vendorTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return t.runGoModCommand(ctx, request, "gopls.vendor", func(uri string) any {
+			return map[string]any{"URI": uri}
+		}, "mod", "vendor")
+	})
+}
+
+// runGoModCommand runs a gopls command against a go.mod file and reports the
+// changes it made to the file. In offline mode the equivalent go command is run
+// directly with GOPROXY=off, leaving the gopls environment untouched, and gopls
+// is told about the changed go.mod and go.sum.
+func (t *LSPTools) runGoModCommand(ctx context.Context, request mcp.CallToolRequest, command string, args func(uri string) any, goArgs ...string) (*mcp.CallToolResult, error) {
+	uri := request.GetString("go_mod_uri", "")
+	if uri == "" {
+		return nil, errors.New("go_mod_uri is required")
+	}
+	if !strings.HasPrefix(uri, "file://") {
+		uri = convertPathToURI(uri)
+	}
+	if !strings.HasSuffix(uri, "/go.mod") {
+		uri = strings.TrimSuffix(uri, "/") + "/go.mod"
+	}
+
+	lspClient := t.getClient()
+	if lspClient == nil {
+		return nil, errors.New("LSP client not available")
+	}
+
+	goModPath := protocol.URIToPath(uri)
+	goSumPath := filepath.Join(filepath.Dir(goModPath), "go.sum")
+
+	before, err := os.ReadFile(goModPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read go.mod: %w", err)
+	}
+	sumBefore, _ := os.ReadFile(goSumPath)
+
+	if err := lspClient.DidOpen(uri, "go.mod", ""); err != nil {
+		return nil, t.handleLSPError(err)
+	}
+
+	if request.GetBool("offline", false) {
+		if err := runOfflineGoCommand(ctx, lspClient, filepath.Dir(goModPath), goArgs...); err != nil {
+			return nil, err
+		}
+		// The go command changed the files behind the back of gopls
+		if err := lspClient.DidChangeFiles(uri, convertPathToURI(goSumPath)); err != nil {
+			return nil, t.handleLSPError(err)
+		}
+	} else if _, err := lspClient.ExecuteCommand(command, args(uri)); err != nil {
+		return nil, t.handleLSPError(err)
+	}
+
+	after, err := os.ReadFile(goModPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read go.mod: %w", err)
+	}
+	sumAfter, _ := os.ReadFile(goSumPath)
+
+	result := goModResult{
+		Command:      command,
+		Changed:      string(before) != string(after),
+		Diff:         diff.Unified("a/go.mod", "b/go.mod", string(before), string(after)),
+		GoSumChanged: string(sumBefore) != string(sumAfter),
+	}
+
+	data, err := json.Marshal(result)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal result: %w", err)
+	}
+
+	return mcp.NewToolResultText(string(data)), nil
+}
+
+// runOfflineGoCommand runs a go command in dir with the environment gopls is
+// configured with and GOPROXY=off, so that only the module cache is used
+func runOfflineGoCommand(ctx context.Context, lspClient client.LSPClient, dir string, args ...string) error {
+	cmd := exec.CommandContext(ctx, "go", args...)
+	cmd.Dir = dir
+	cmd.Env = os.Environ()
+	if env, ok := lspClient.Settings()["env"].(map[string]any); ok {
+		for k, v := range env {
+			cmd.Env = append(cmd.Env, fmt.Sprintf("%s=%v", k, v))
+		}
+	}
+	cmd.Env = append(cmd.Env, "GOPROXY=off")
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("go %s failed: %w: %s", strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+func (t *LSPTools) registerHover(s *server.MCPServer) {
+	hoverTool := mcp.NewTool("hover",
+		mcp.WithDescription("SYMBOL DOCS AND SIGNATURE: Use this LSP tool to get the type, signature and documentation of the symbol at a position without opening the file where it is declared. Use this when: 1) You need the exact signature of a function or method before calling it, 2) You want to know the type of a variable or expression, 3) You need the doc comment of a type, field or package member. Returns the gopls hover text as markdown."),
+		mcp.WithString("file_uri",
+			mcp.Required(),
+			mcp.Description("URI or absolute path of the file containing the symbol. Can be a file:// URI or absolute path like /path/to/file.go"),
+		),
+		mcp.WithObject("position",
+			mcp.Required(),
+			mcp.Description("Position of the symbol to describe. Must contain 'line' (0-indexed line number) and 'character' (0-indexed column number) keys"),
+		),
+		contentOption(),
+	)
+
+	s.AddTool(hoverTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		fileURI := request.GetString("file_uri", "")
+		if fileURI == "" {
+			return nil, errors.New("file_uri is required")
+		}
+
+		position, err := parsePosition(request.GetArguments()["position"])
+		if err != nil {
+			return nil, err
+		}
+
+		if !strings.HasPrefix(fileURI, "file://") {
+			fileURI = convertPathToURI(fileURI)
+		}
+
+		lspClient := t.getClient()
+		if lspClient == nil {
+			return nil, errors.New("LSP client not available")
+		}
+
+		var hover string
+		err = withOverlay(lspClient, fileURI, contentArg(request), func() (err error) {
+			hover, err = lspClient.GetHover(fileURI, position.Line, position.Character)
+			return err
+		})
+		if err != nil {
+			return nil, t.handleLSPError(err)
+		}
+
+		return mcp.NewToolResultText(hover), nil
+	})
+}
@@ -0,0 +1,320 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/solatis/mcp-gopls/pkg/lsp/client"
+	"github.com/solatis/mcp-gopls/pkg/lsp/protocol"
+)
+
+const (
+	defaultImpactDepth     = 3
+	defaultImpactFunctions = 200
+
+	impactViaCall      = "call"
+	impactViaInterface = "interface"
+)
+
+// impactNode is a function affected by a change, with the chain of calls and
+// interface dispatches leading to it from the changed symbol
+type impactNode struct {
+	Name    string `json:"name"`
+	Package string `json:"package"`
+	URI     string `json:"uri"`
+	Line    int    `json:"line"`
+	Depth   int    `json:"depth"`
+	// Via tells how the node reaches its predecessor in Path: by calling it,
+	// or as the interface method it implements
+	Via  string   `json:"via,omitempty"`
+	Path []string `json:"path"`
+
+	item protocol.CallHierarchyItem
+}
+
+type impactResult struct {
+	Symbol         impactNode   `json:"symbol"`
+	Functions      []impactNode `json:"functions"`
+	APIEntryPoints []impactNode `json:"api_entry_points"`
+	Tests          []impactNode `json:"tests"`
+	Packages       []string     `json:"packages"`
+	Truncated      bool         `json:"truncated,omitempty"`
+}
+
+// isAPIName reports whether a function or method name, as in document
+// symbols ("Serve", "(*Server).Start"), is exported along with its receiver
+func isAPIName(name string) bool {
+	if !strings.HasPrefix(name, "(") {
+		return isExportedSymbol(name)
+	}
+	end := strings.Index(name, ")")
+	if end < 0 {
+		return false
+	}
+	receiver := strings.TrimPrefix(name[1:end], "*")
+	if i := strings.Index(receiver, "["); i >= 0 {
+		receiver = receiver[:i]
+	}
+	return isExportedSymbol(receiver) && isExportedSymbol(name[end+1:])
+}
+
+func (t *LSPTools) registerImpactAnalysis(s *server.MCPServer) {
+	impactTool := mcp.NewTool("impact_analysis",
+		mcp.WithDescription("WHAT BREAKS IF I CHANGE THIS? Use this tool before modifying a function or method to know everything that could be affected. Walks the callers transitively through the call hierarchy, including calls made through the interfaces a method implements, up to a number of levels. Returns the affected functions, the exported API entry points and the test functions, each with the chain of calls linking it to the changed symbol, and the affected packages."),
+		mcp.WithString("name",
+			mcp.Description("Name of the function or method, optionally qualified by its type and package, e.g. 'Serve', 'Server.Start' or 'server.Server.Start'. Alternative to file_uri and position"),
+		),
+		mcp.WithString("file_uri",
+			mcp.Description("URI or absolute path of a file calling or declaring the function, with position"),
+		),
+		mcp.WithObject("position",
+			mcp.Description("Position of the function or method in file_uri. Must contain 'line' (0-indexed line number) and 'character' (0-indexed column number) keys"),
+		),
+		mcp.WithNumber("max_depth",
+			mcp.Description(fmt.Sprintf("Number of caller levels to walk (default %d)", defaultImpactDepth)),
+			mcp.Min(1),
+		),
+		mcp.WithNumber("max_functions",
+			mcp.Description(fmt.Sprintf("Maximum number of affected functions to report (default %d)", defaultImpactFunctions)),
+			mcp.Min(1),
+		),
+	)
+
+	s.AddTool(impactTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		name := request.GetString("name", "")
+		fileURI := request.GetString("file_uri", "")
+		positionArg := request.GetArguments()["position"]
+		maxDepth := request.GetInt("max_depth", defaultImpactDepth)
+		maxFunctions := request.GetInt("max_functions", defaultImpactFunctions)
+
+		lspClient := t.getClient()
+		if lspClient == nil {
+			return nil, errors.New("LSP client not available")
+		}
+
+		var uri string
+		var pos protocol.Position
+		switch {
+		case positionArg != nil:
+			if fileURI == "" {
+				return nil, errors.New("file_uri is required with a position")
+			}
+			if !strings.HasPrefix(fileURI, "file://") {
+				fileURI = convertPathToURI(fileURI)
+			}
+			var err error
+			if pos, err = parsePosition(positionArg); err != nil {
+				return nil, err
+			}
+			uri = fileURI
+		case name != "":
+			symbol, err := resolveSymbol(lspClient, name)
+			if err != nil {
+				return nil, t.handleLSPError(err)
+			}
+			uri, pos = symbol.Location.URI, symbol.Location.Range.Start
+		default:
+			return nil, errors.New("either name or file_uri and position are required")
+		}
+
+		items, err := lspClient.PrepareCallHierarchy(uri, pos.Line, pos.Character)
+		if err != nil {
+			return nil, t.handleLSPError(err)
+		}
+		if len(items) == 0 {
+			return nil, fmt.Errorf("no function or method at %s:%d:%d", uri, pos.Line, pos.Character)
+		}
+
+		result, err := t.analyzeImpact(ctx, lspClient, items[0], maxDepth, maxFunctions)
+		if err != nil {
+			return nil, t.handleLSPError(err)
+		}
+
+		data, err := json.Marshal(result)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal result: %w", err)
+		}
+
+		return mcp.NewToolResultText(string(data)), nil
+	})
+}
+
+// analyzeImpact walks the callers of root breadth first. Methods also lead to
+// the interface methods they implement, whose callers may dispatch to them;
+// that step does not count as a level. Test functions are not walked further.
+func (t *LSPTools) analyzeImpact(ctx context.Context, lspClient client.LSPClient, root protocol.CallHierarchyItem, maxDepth, maxFunctions int) (*impactResult, error) {
+	cache := newSymbolCache(lspClient)
+	itemKey := func(item protocol.CallHierarchyItem) string {
+		return fmt.Sprintf("%s:%d:%d", item.URI, item.SelectionRange.Start.Line, item.SelectionRange.Start.Character)
+	}
+
+	newNode := func(item protocol.CallHierarchyItem, parent *impactNode, via string) (*impactNode, error) {
+		node := &impactNode{
+			Name:    item.Name,
+			Package: packageDirOf(item.URI),
+			URI:     item.URI,
+			Line:    item.SelectionRange.Start.Line,
+			Via:     via,
+			item:    item,
+		}
+		// Document symbols qualify methods with their receiver, and
+		// interface methods are named after their interface
+		symbol, err := cache.enclosing(item.URI, item.SelectionRange.Start)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case symbol == nil:
+		case symbol.Kind == protocol.SKFunction || symbol.Kind == protocol.SKMethod:
+			node.Name = symbol.Name
+		case symbol.Kind == protocol.SKInterface:
+			node.Name = symbol.Name + "." + item.Name
+		}
+		if parent != nil {
+			node.Depth = parent.Depth
+			if via == impactViaCall {
+				node.Depth++
+			}
+			node.Path = append(append([]string{}, parent.Path...), node.Name)
+		} else {
+			node.Path = []string{node.Name}
+		}
+		return node, nil
+	}
+
+	start, err := newNode(root, nil, "")
+	if err != nil {
+		return nil, err
+	}
+	result := &impactResult{
+		Symbol:         *start,
+		Functions:      []impactNode{},
+		APIEntryPoints: []impactNode{},
+		Tests:          []impactNode{},
+		Packages:       []string{},
+	}
+
+	seen := map[string]bool{itemKey(root): true}
+	packages := map[string]bool{start.Package: true}
+	queue := []*impactNode{start}
+
+	add := func(node *impactNode) bool {
+		if len(result.Functions)+len(result.APIEntryPoints)+len(result.Tests) >= maxFunctions {
+			result.Truncated = true
+			return false
+		}
+		packages[node.Package] = true
+		switch {
+		case strings.HasSuffix(node.URI, "_test.go") && testFunctionKind(node.Name) != "":
+			result.Tests = append(result.Tests, *node)
+			return true
+		case !strings.HasSuffix(node.URI, "_test.go") && isAPIName(node.Name) && !strings.Contains(node.Package+string(filepath.Separator), string(filepath.Separator)+"internal"+string(filepath.Separator)):
+			result.APIEntryPoints = append(result.APIEntryPoints, *node)
+		default:
+			result.Functions = append(result.Functions, *node)
+		}
+		queue = append(queue, node)
+		return true
+	}
+
+walk:
+	for len(queue) > 0 {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		current := queue[0]
+		queue = queue[1:]
+
+		// Callers through interfaces: the interface methods implemented by
+		// a concrete method
+		if current.item.Kind == protocol.SKMethod && current.Via != impactViaInterface {
+			pos := current.item.SelectionRange.Start
+			abstract, err := lspClient.GetImplementations(current.URI, pos.Line, pos.Character)
+			if err == nil {
+				for _, location := range abstract {
+					items, err := lspClient.PrepareCallHierarchy(location.URI, location.Range.Start.Line, location.Range.Start.Character)
+					if err != nil {
+						return nil, err
+					}
+					for _, item := range items {
+						if seen[itemKey(item)] {
+							continue
+						}
+						seen[itemKey(item)] = true
+						node, err := newNode(item, current, impactViaInterface)
+						if err != nil {
+							return nil, err
+						}
+						if !add(node) {
+							break walk
+						}
+					}
+				}
+			}
+		}
+
+		if current.Depth >= maxDepth || (strings.HasSuffix(current.URI, "_test.go") && testFunctionKind(current.Name) != "") {
+			continue
+		}
+
+		calls, err := lspClient.IncomingCalls(current.item)
+		if err != nil {
+			return nil, err
+		}
+		for _, call := range calls {
+			if seen[itemKey(call.From)] {
+				continue
+			}
+			seen[itemKey(call.From)] = true
+			node, err := newNode(call.From, current, impactViaCall)
+			if err != nil {
+				return nil, err
+			}
+			if !add(node) {
+				break walk
+			}
+		}
+	}
+
+	for dir := range packages {
+		result.Packages = append(result.Packages, dir)
+	}
+	sort.Strings(result.Packages)
+
+	// Report import paths rather than directories when go list knows them
+	if listed, err := t.goRunner().ListPackages(ctx, result.Packages[0], false, result.Packages...); err == nil {
+		importPaths := make(map[string]string)
+		for _, pkg := range listed {
+			importPaths[pkg.Dir] = pkg.ImportPath
+		}
+		rename := func(nodes []impactNode) {
+			for i := range nodes {
+				if importPath, ok := importPaths[nodes[i].Package]; ok {
+					nodes[i].Package = importPath
+				}
+			}
+		}
+		rename(result.Functions)
+		rename(result.APIEntryPoints)
+		rename(result.Tests)
+		if importPath, ok := importPaths[result.Symbol.Package]; ok {
+			result.Symbol.Package = importPath
+		}
+		for i, dir := range result.Packages {
+			if importPath, ok := importPaths[dir]; ok {
+				result.Packages[i] = importPath
+			}
+		}
+		sort.Strings(result.Packages)
+	}
+
+	return result, nil
+}
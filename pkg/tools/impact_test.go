@@ -0,0 +1,23 @@
+package tools
+
+import "testing"
+
+func TestIsAPIName(t *testing.T) {
+	tests := map[string]bool{
+		"Serve":              true,
+		"serve":              false,
+		"(*Server).Start":    true,
+		"(Server).Start":     true,
+		"(*Server).start":    false,
+		"(*server).Start":    false,
+		"(*Cache[K, V]).Get": true,
+		"(Cache[K]).get":     false,
+		"(*cache[K, V]).Get": false,
+		"(*Server":           false,
+	}
+	for name, want := range tests {
+		if got := isAPIName(name); got != want {
+			t.Errorf("isAPIName(%q) = %v, want %v", name, got, want)
+		}
+	}
+}
@@ -0,0 +1,452 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/solatis/mcp-gopls/pkg/diff"
+	"github.com/solatis/mcp-gopls/pkg/lsp/client"
+	"github.com/solatis/mcp-gopls/pkg/lsp/protocol"
+)
+
+// typeSymbolKinds are the kinds gopls reports for type declarations
+var typeSymbolKinds = map[protocol.SymbolKind]bool{
+	protocol.SKClass:         true,
+	protocol.SKStruct:        true,
+	protocol.SKInterface:     true,
+	protocol.SKArray:         true,
+	protocol.SKObject:        true,
+	protocol.SKNumber:        true,
+	protocol.SKString:        true,
+	protocol.SKBoolean:       true,
+	protocol.SKEnum:          true,
+	protocol.SKTypeParameter: true,
+}
+
+// typeRef locates a named type declaration
+type typeRef struct {
+	Name     string            `json:"name"`
+	URI      string            `json:"uri"`
+	Position protocol.Position `json:"position"`
+	Kind     string            `json:"kind"`
+}
+
+// resolveType finds a type declaration either by name, optionally qualified
+// by its package name or path ("tools.LSPTools"), or by a position within its
+// declaration.
+func resolveType(lspClient client.LSPClient, name, fileURI string, positionArg any) (typeRef, error) {
+	if positionArg != nil {
+		if fileURI == "" {
+			return typeRef{}, errors.New("a file URI is required with a position")
+		}
+		if !strings.HasPrefix(fileURI, "file://") {
+			fileURI = convertPathToURI(fileURI)
+		}
+		pos, err := parsePosition(positionArg)
+		if err != nil {
+			return typeRef{}, err
+		}
+
+		symbol, err := newSymbolCache(lspClient).enclosing(fileURI, pos)
+		if err != nil {
+			return typeRef{}, err
+		}
+		if symbol == nil || !typeSymbolKinds[symbol.Kind] {
+			return typeRef{}, fmt.Errorf("no type declaration at %s:%d:%d", fileURI, pos.Line, pos.Character)
+		}
+		return typeRef{
+			Name:     symbol.Name,
+			URI:      fileURI,
+			Position: symbol.SelectionRange.Start,
+			Kind:     symbol.Kind.String(),
+		}, nil
+	}
+
+	if name == "" {
+		return typeRef{}, errors.New("either a type name or a file URI and position are required")
+	}
+
+	qualifier := ""
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		qualifier, name = name[:i], name[i+1:]
+	}
+
+	symbols, err := lspClient.GetWorkspaceSymbols(name)
+	if err != nil {
+		return typeRef{}, err
+	}
+
+	var matches []protocol.SymbolInformation
+	for _, symbol := range symbols {
+		if symbol.Name != name || !typeSymbolKinds[symbol.Kind] {
+			continue
+		}
+		// gopls reports the package path of workspace symbols as container
+		if qualifier != "" && symbol.ContainerName != qualifier && path.Base(symbol.ContainerName) != qualifier {
+			continue
+		}
+		matches = append(matches, symbol)
+	}
+	matches = preferNonTest(matches)
+
+	switch len(matches) {
+	case 0:
+		return typeRef{}, fmt.Errorf("type %s not found in the workspace", name)
+	case 1:
+		return typeRef{
+			Name:     matches[0].Name,
+			URI:      matches[0].Location.URI,
+			Position: matches[0].Location.Range.Start,
+			Kind:     matches[0].Kind.String(),
+		}, nil
+	default:
+		var packages []string
+		for _, match := range matches {
+			packages = append(packages, match.ContainerName)
+		}
+		return typeRef{}, fmt.Errorf("type %s is ambiguous, qualify it with one of the packages %s", name, strings.Join(packages, ", "))
+	}
+}
+
+// preferNonTest drops the symbols declared in _test.go files when any of the
+// symbols is declared in a non-test file
+func preferNonTest(symbols []protocol.SymbolInformation) []protocol.SymbolInformation {
+	var nonTest []protocol.SymbolInformation
+	for _, symbol := range symbols {
+		if !strings.HasSuffix(protocol.URIToPath(symbol.Location.URI), "_test.go") {
+			nonTest = append(nonTest, symbol)
+		}
+	}
+	if len(nonTest) == 0 {
+		return symbols
+	}
+	return nonTest
+}
+
+// fileImports returns the imports of a Go file, mapping import paths to the
+// name they are imported as ("" when not renamed)
+func fileImports(content string) (map[string]string, error) {
+	f, err := parser.ParseFile(token.NewFileSet(), "", content, parser.ImportsOnly)
+	if err != nil {
+		return nil, err
+	}
+
+	imports := make(map[string]string)
+	for _, spec := range f.Imports {
+		importPath, _ := strconv.Unquote(spec.Path.Value)
+		imports[importPath] = ""
+		if spec.Name != nil {
+			imports[importPath] = spec.Name.Name
+		}
+	}
+	return imports, nil
+}
+
+type implementResult struct {
+	Type      typeRef  `json:"type"`
+	Interface string   `json:"interface"`
+	Applied   bool     `json:"applied"`
+	Changed   bool     `json:"changed"`
+	Diff      string   `json:"diff,omitempty"`
+	Notes     []string `json:"notes,omitempty"`
+	// Diagnostics are those of the file after the change, when applied
+	Diagnostics []protocol.Diagnostic `json:"diagnostics,omitempty"`
+}
+
+func (t *LSPTools) registerImplementInterface(s *server.MCPServer) {
+	implementTool := mcp.NewTool("implement_interface",
+		mcp.WithDescription("MAKE A TYPE IMPLEMENT AN INTERFACE: Use this tool instead of writing method stubs by hand. Generates the methods a concrete type is missing to implement an interface, with the right receivers, signatures and imports, through the gopls stub-methods quick fix. Identify the type and interface by name or by location. Returns the diff; applies it unless dry_run is set."),
+		mcp.WithString("type_name",
+			mcp.Description("Name of the concrete type, optionally qualified by its package name or path, e.g. 'Server' or 'server.Server'"),
+		),
+		mcp.WithString("type_file_uri",
+			mcp.Description("URI or absolute path of the file declaring the type, with type_position"),
+		),
+		mcp.WithObject("type_position",
+			mcp.Description("Position within the type declaration. Must contain 'line' (0-indexed line number) and 'character' (0-indexed column number) keys"),
+		),
+		mcp.WithString("interface",
+			mcp.Description("Interface as written in the file of the type, e.g. 'io.Reader', 'http.Handler' or 'Store' for one of the same package"),
+		),
+		mcp.WithString("interface_import_path",
+			mcp.Description("Import path of the interface's package, when the file of the type does not import it yet, e.g. 'net/http'"),
+		),
+		mcp.WithString("interface_file_uri",
+			mcp.Description("URI or absolute path of the file declaring the interface, with interface_position, instead of 'interface'"),
+		),
+		mcp.WithObject("interface_position",
+			mcp.Description("Position within the interface declaration. Must contain 'line' (0-indexed line number) and 'character' (0-indexed column number) keys"),
+		),
+		mcp.WithBoolean("pointer_receiver",
+			mcp.Description("Implement the interface with the pointer type *T (default true)"),
+		),
+		mcp.WithBoolean("keep_assertion",
+			mcp.Description("Keep a 'var _ Interface = (*T)(nil)' compile-time check after the stubs (default false)"),
+		),
+		mcp.WithBoolean("dry_run",
+			mcp.Description("Only return the diff, leaving the file unchanged"),
+		),
+	)
+
+	s.AddTool(implementTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		lspClient := t.getClient()
+		if lspClient == nil {
+			return nil, errors.New("LSP client not available")
+		}
+
+		args := request.GetArguments()
+		typ, err := resolveType(lspClient, request.GetString("type_name", ""), request.GetString("type_file_uri", ""), args["type_position"])
+		if err != nil {
+			return nil, t.handleLSPError(err)
+		}
+		if typ.Kind == protocol.SKInterface.String() {
+			return nil, fmt.Errorf("%s is an interface, not a concrete type", typ.Name)
+		}
+
+		typeFile := protocol.URIToPath(typ.URI)
+		iface := request.GetString("interface", "")
+		importPath := request.GetString("interface_import_path", "")
+
+		if iface == "" {
+			ifaceRef, err := resolveType(lspClient, "", request.GetString("interface_file_uri", ""), args["interface_position"])
+			if err != nil {
+				return nil, t.handleLSPError(err)
+			}
+			if ifaceRef.Kind != protocol.SKInterface.String() {
+				return nil, fmt.Errorf("%s is not an interface", ifaceRef.Name)
+			}
+			iface = ifaceRef.Name
+
+			ifaceDir := filepath.Dir(protocol.URIToPath(ifaceRef.URI))
+			if ifaceDir != filepath.Dir(typeFile) {
+				packages, err := t.goRunner().ListPackages(ctx, ifaceDir, false, ".")
+				if err != nil || len(packages) != 1 {
+					return nil, fmt.Errorf("failed to find the package of %s: %v", ifaceRef.Name, err)
+				}
+				importPath = packages[0].ImportPath
+				iface = packages[0].Name + "." + iface
+			}
+		}
+
+		original, err := os.ReadFile(typeFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read file: %w", err)
+		}
+		if isGenericType(string(original), typ.Name) {
+			return nil, fmt.Errorf("%s is a generic type, implement the interface on an instantiation of it by hand", typ.Name)
+		}
+
+		// The stubs are generated in an overlay, so the file on disk is
+		// only written when they are applied
+		if err := lspClient.SetOverlay(typ.URI, string(original)); err != nil {
+			return nil, t.handleLSPError(err)
+		}
+		final, notes, err := stubInterfaceMethods(lspClient, typ, iface, importPath, string(original),
+			request.GetBool("pointer_receiver", true), request.GetBool("keep_assertion", false))
+		if clearErr := lspClient.ClearOverlay(typ.URI); clearErr != nil {
+			err = errors.Join(err, clearErr)
+		}
+		if err != nil {
+			return nil, t.handleLSPError(err)
+		}
+
+		result := implementResult{Type: typ, Interface: iface}
+		name := filepath.Base(typeFile)
+		result.Applied = !request.GetBool("dry_run", false)
+		result.Changed = final != string(original)
+		result.Diff = diff.Unified("a/"+name, "b/"+name, string(original), final)
+		result.Notes = notes
+
+		if result.Applied {
+			if result.Changed {
+				if err := os.WriteFile(typeFile, []byte(final), 0o644); err != nil {
+					return nil, fmt.Errorf("failed to write %s: %w", typeFile, err)
+				}
+				if err := lspClient.DidOpen(typ.URI, "go", final); err != nil {
+					return nil, t.handleLSPError(err)
+				}
+			}
+			diagnostics, err := lspClient.GetDiagnostics(typ.URI)
+			if err != nil {
+				return nil, t.handleLSPError(err)
+			}
+			result.Diagnostics = diagnostics
+		}
+
+		data, err := json.Marshal(result)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal result: %w", err)
+		}
+
+		return mcp.NewToolResultText(string(data)), nil
+	})
+}
+
+// isGenericType reports whether the file content declares name with type
+// parameters, for which "(*T)(nil)" is not a valid expression
+func isGenericType(content, name string) bool {
+	f, err := parser.ParseFile(token.NewFileSet(), "", content, parser.SkipObjectResolution)
+	if err != nil {
+		return false
+	}
+
+	generic := false
+	ast.Inspect(f, func(n ast.Node) bool {
+		if spec, ok := n.(*ast.TypeSpec); ok && spec.Name.Name == name {
+			generic = spec.TypeParams != nil && len(spec.TypeParams.List) > 0
+			return false
+		}
+		return !generic
+	})
+	return generic
+}
+
+// stubInterfaceMethods adds the methods typ is missing to implement iface to
+// the overlay of the file of typ and returns the new content of the file. gopls
+// only offers its stub-methods fix on a failing conversion, so an assertion
+// "var _ iface = (*T)(nil)" is appended to the file first and removed after.
+func stubInterfaceMethods(lspClient client.LSPClient, typ typeRef, iface, importPath, original string, pointer, keepAssertion bool) (string, []string, error) {
+	typeFile := protocol.URIToPath(typ.URI)
+	var notes []string
+
+	write := func(content string) error {
+		return lspClient.DidChange(typ.URI, content)
+	}
+	// gopls applies its edits to the overlay
+	read := func() (string, error) {
+		content, ok := lspClient.Overlay(typ.URI)
+		if !ok {
+			return "", fmt.Errorf("the overlay of %s was cleared", typeFile)
+		}
+		return content, nil
+	}
+
+	content := original
+	addedImport := false
+	if importPath != "" {
+		imports, err := fileImports(content)
+		if err != nil {
+			return "", nil, fmt.Errorf("failed to parse %s: %w", typeFile, err)
+		}
+		if alias, ok := imports[importPath]; ok {
+			// Use the name the file imports the package as
+			if alias != "" && alias != "_" && alias != "." {
+				if _, name, ok := strings.Cut(iface, "."); ok {
+					iface = alias + "." + name
+				}
+			}
+		} else {
+			if _, err := lspClient.ExecuteCommand("gopls.add_import", map[string]any{
+				"ImportPath": importPath,
+				"URI":        typ.URI,
+			}); err != nil {
+				return "", nil, err
+			}
+			if content, err = read(); err != nil {
+				return "", nil, err
+			}
+			addedImport = true
+		}
+	}
+
+	value := fmt.Sprintf("(*%s)(nil)", typ.Name)
+	if !pointer {
+		value = fmt.Sprintf("*new(%s)", typ.Name)
+	}
+	assertion := fmt.Sprintf("var _ %s = %s", iface, value)
+	if !strings.HasSuffix(content, "\n") {
+		content += "\n"
+	}
+	assertionLine := strings.Count(content, "\n") + 1
+	content += "\n" + assertion + "\n"
+	if err := write(content); err != nil {
+		return "", nil, err
+	}
+
+	// The quick fix applies to the type error of the assertion
+	diagnostics, err := lspClient.GetDiagnostics(typ.URI)
+	if err != nil {
+		return "", nil, err
+	}
+	failing := false
+	for _, d := range diagnostics {
+		if d.Range.Start.Line == assertionLine {
+			failing = true
+			if !strings.Contains(d.Message, "method") {
+				return "", nil, fmt.Errorf("cannot implement %s: %s", iface, d.Message)
+			}
+		}
+	}
+	if !failing {
+		notes = append(notes, fmt.Sprintf("%s already implements %s", value, iface))
+	} else {
+		rng := protocol.Range{
+			Start: protocol.Position{Line: assertionLine},
+			End:   protocol.Position{Line: assertionLine, Character: len(assertion)},
+		}
+		actions, err := lspClient.CodeActions(typ.URI, rng, "quickfix")
+		if err != nil {
+			return "", nil, err
+		}
+
+		var stub *protocol.CodeAction
+		for i, action := range actions {
+			if action.Disabled == nil && (strings.Contains(action.Title, "missing method") || strings.HasPrefix(action.Title, "Implement ")) {
+				stub = &actions[i]
+				break
+			}
+		}
+		if stub == nil {
+			_, err := selectCodeAction(actions, "quickfix")
+			return "", nil, fmt.Errorf("gopls offers no stub-methods fix for %s: %w", iface, err)
+		}
+		if err := lspClient.ApplyCodeAction(*stub); err != nil {
+			return "", nil, err
+		}
+	}
+
+	if content, err = read(); err != nil {
+		return "", nil, err
+	}
+
+	if !keepAssertion {
+		marker := "\n" + assertion + "\n"
+		if i := strings.LastIndex(content, marker); i >= 0 {
+			content = content[:i] + content[i+len(marker):]
+		}
+	}
+	if err := write(content); err != nil {
+		return "", nil, err
+	}
+
+	// The import was only needed by the assertion if no stub uses it
+	if addedImport && !keepAssertion {
+		actions, err := lspClient.CodeActions(typ.URI, protocol.Range{}, "source.organizeImports")
+		if err != nil {
+			return "", nil, err
+		}
+		if action, err := selectCodeAction(actions, "source.organizeImports"); err == nil {
+			if err := lspClient.ApplyCodeAction(action); err != nil {
+				return "", nil, err
+			}
+			if content, err = read(); err != nil {
+				return "", nil, err
+			}
+		}
+	}
+
+	return content, notes, nil
+}
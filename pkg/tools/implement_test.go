@@ -0,0 +1,104 @@
+package tools
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/solatis/mcp-gopls/pkg/lsp/client"
+	"github.com/solatis/mcp-gopls/pkg/lsp/protocol"
+)
+
+// fakeWorkspaceClient answers workspace symbol queries from a fixed list
+type fakeWorkspaceClient struct {
+	client.LSPClient
+	workspace []protocol.SymbolInformation
+}
+
+func (c *fakeWorkspaceClient) GetWorkspaceSymbols(query string) ([]protocol.SymbolInformation, error) {
+	var symbols []protocol.SymbolInformation
+	for _, symbol := range c.workspace {
+		if strings.Contains(symbol.Name, query) {
+			symbols = append(symbols, symbol)
+		}
+	}
+	return symbols, nil
+}
+
+func symbolIn(name, container, uri string) protocol.SymbolInformation {
+	return protocol.SymbolInformation{
+		Name:          name,
+		Kind:          protocol.SKStruct,
+		ContainerName: container,
+		Location:      protocol.Location{URI: uri},
+	}
+}
+
+const implementSource = `package store
+
+import (
+	"context"
+	stdio "io"
+)
+
+type Store struct{}
+
+type Cache[K comparable, V any] struct{}
+
+type (
+	List[T any] []T
+	Names       []string
+)
+`
+
+func TestIsGenericType(t *testing.T) {
+	tests := map[string]bool{
+		"Store":   false,
+		"Cache":   true,
+		"List":    true,
+		"Names":   false,
+		"Missing": false,
+	}
+	for name, want := range tests {
+		if got := isGenericType(implementSource, name); got != want {
+			t.Errorf("isGenericType(%s) = %v, want %v", name, got, want)
+		}
+	}
+}
+
+func TestFileImports(t *testing.T) {
+	imports, err := fileImports(implementSource)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"context": "", "io": "stdio"}
+	if !reflect.DeepEqual(imports, want) {
+		t.Errorf("fileImports = %v, want %v", imports, want)
+	}
+}
+
+func TestResolveTypePrefersNonTestFiles(t *testing.T) {
+	inTest := symbolIn("Store", "example.com/app/store", "file:///src/app/store/store_test.go")
+	inSource := symbolIn("Store", "example.com/app/store", "file:///src/app/store/store.go")
+
+	tests := []struct {
+		name      string
+		workspace []protocol.SymbolInformation
+		want      string
+	}{
+		{"test file reported first", []protocol.SymbolInformation{inTest, inSource}, inSource.Location.URI},
+		{"test file reported last", []protocol.SymbolInformation{inSource, inTest}, inSource.Location.URI},
+		{"only in a test file", []protocol.SymbolInformation{inTest}, inTest.Location.URI},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			typ, err := resolveType(&fakeWorkspaceClient{workspace: tt.workspace}, "store.Store", "", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if typ.URI != tt.want {
+				t.Errorf("resolved %s, want %s", typ.URI, tt.want)
+			}
+		})
+	}
+}
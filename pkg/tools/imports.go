@@ -0,0 +1,236 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/solatis/mcp-gopls/pkg/diff"
+	"github.com/solatis/mcp-gopls/pkg/lsp/protocol"
+)
+
+// defaultPackageLimit is the number of packages list_known_packages returns
+// when no limit is given
+const defaultPackageLimit = 50
+
+// knownPackage is a package that can be imported from a file
+type knownPackage struct {
+	Path     string `json:"path"`
+	Name     string `json:"name"`
+	Standard bool   `json:"standard"`
+}
+
+type knownPackagesResult struct {
+	Total     int            `json:"total"`
+	Packages  []knownPackage `json:"packages"`
+	Truncated bool           `json:"truncated,omitempty"`
+}
+
+type addImportResult struct {
+	ImportPath string `json:"import_path"`
+	Changed    bool   `json:"changed"`
+	Diff       string `json:"diff,omitempty"`
+}
+
+// packageMatchRank ranks how well an import path matches a query, lower being
+// better, or returns -1 when it does not match. The last path element, which
+// usually is the package name, matters most.
+func packageMatchRank(importPath, query string) int {
+	if query == "" {
+		return 0
+	}
+
+	importPath = strings.ToLower(importPath)
+	name := path.Base(importPath)
+	switch {
+	case name == query:
+		return 0
+	case strings.HasPrefix(name, query):
+		return 1
+	case strings.Contains(name, query):
+		return 2
+	case strings.Contains(importPath, query):
+		return 3
+	default:
+		return -1
+	}
+}
+
+// isStandardImportPath reports whether an import path belongs to the standard
+// library, whose first path element has no dot
+func isStandardImportPath(importPath string) bool {
+	first, _, _ := strings.Cut(importPath, "/")
+	return !strings.Contains(first, ".")
+}
+
+func (t *LSPTools) registerImportTools(s *server.MCPServer) {
+	listPackagesTool := mcp.NewTool("list_known_packages",
+		mcp.WithDescription("FIND THE RIGHT IMPORT PATH: Use this tool instead of guessing import paths. Searches the packages that can be imported from a file (standard library, module dependencies and workspace packages) by a name fragment, best matches first. Use this when: 1) Writing code that needs a package you don't know the path of, 2) Checking a dependency is available before importing it."),
+		mcp.WithString("file_uri",
+			mcp.Required(),
+			mcp.Description("URI or absolute path of the Go file that would import the package"),
+		),
+		mcp.WithString("query",
+			mcp.Description("Fragment of the package name or import path, e.g. 'errgroup' or 'x/sync' (case-insensitive). Lists all packages when empty"),
+		),
+		mcp.WithNumber("limit",
+			mcp.Description(fmt.Sprintf("Maximum number of packages to return (default %d)", defaultPackageLimit)),
+			mcp.Min(1),
+		),
+	)
+
+	s.AddTool(listPackagesTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		fileURI := request.GetString("file_uri", "")
+		if fileURI == "" {
+			return nil, errors.New("file_uri is required")
+		}
+		if !strings.HasPrefix(fileURI, "file://") {
+			fileURI = convertPathToURI(fileURI)
+		}
+		query := strings.ToLower(request.GetString("query", ""))
+		limit := request.GetInt("limit", defaultPackageLimit)
+
+		lspClient := t.getClient()
+		if lspClient == nil {
+			return nil, errors.New("LSP client not available")
+		}
+
+		// gopls only answers for open files
+		if _, err := lspClient.GetDiagnostics(fileURI); err != nil {
+			return nil, t.handleLSPError(err)
+		}
+
+		raw, err := lspClient.ExecuteCommand("gopls.list_known_packages", map[string]any{"URI": fileURI})
+		if err != nil {
+			return nil, t.handleLSPError(err)
+		}
+
+		var response struct {
+			Packages []string `json:"Packages"`
+		}
+		if err := json.Unmarshal(raw, &response); err != nil {
+			return nil, fmt.Errorf("failed to parse known packages: %w", err)
+		}
+
+		ranks := make(map[string]int)
+		matches := []string{}
+		for _, importPath := range response.Packages {
+			if rank := packageMatchRank(importPath, query); rank >= 0 {
+				ranks[importPath] = rank
+				matches = append(matches, importPath)
+			}
+		}
+		sort.SliceStable(matches, func(i, j int) bool {
+			if ranks[matches[i]] != ranks[matches[j]] {
+				return ranks[matches[i]] < ranks[matches[j]]
+			}
+			// Shorter paths first, so that "errors" comes before "x/errors"
+			if len(matches[i]) != len(matches[j]) {
+				return len(matches[i]) < len(matches[j])
+			}
+			return matches[i] < matches[j]
+		})
+
+		result := knownPackagesResult{
+			Total:    len(matches),
+			Packages: []knownPackage{},
+		}
+		if len(matches) > limit {
+			matches = matches[:limit]
+			result.Truncated = true
+		}
+		for _, importPath := range matches {
+			result.Packages = append(result.Packages, knownPackage{
+				Path:     importPath,
+				Name:     path.Base(importPath),
+				Standard: isStandardImportPath(importPath),
+			})
+		}
+
+		data, err := json.Marshal(result)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal result: %w", err)
+		}
+
+		return mcp.NewToolResultText(string(data)), nil
+	})
+
+	addImportTool := mcp.NewTool("add_import",
+		mcp.WithDescription("ADD AN IMPORT CORRECTLY: Use this tool instead of editing the import block by hand. Adds an import to a Go file the way gopls does, in the right group and order, and returns the diff of the file. Use list_known_packages first when unsure of the import path."),
+		mcp.WithString("file_uri",
+			mcp.Required(),
+			mcp.Description("URI or absolute path of the Go file to add the import to"),
+		),
+		mcp.WithString("import_path",
+			mcp.Required(),
+			mcp.Description("Import path of the package, e.g. 'golang.org/x/sync/errgroup'"),
+		),
+	)
+
+	s.AddTool(addImportTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		fileURI := request.GetString("file_uri", "")
+		if fileURI == "" {
+			return nil, errors.New("file_uri is required")
+		}
+		if !strings.HasPrefix(fileURI, "file://") {
+			fileURI = convertPathToURI(fileURI)
+		}
+		importPath := request.GetString("import_path", "")
+		if importPath == "" {
+			return nil, errors.New("import_path is required")
+		}
+
+		lspClient := t.getClient()
+		if lspClient == nil {
+			return nil, errors.New("LSP client not available")
+		}
+
+		filePath := protocol.URIToPath(fileURI)
+		before, err := os.ReadFile(filePath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read file: %w", err)
+		}
+
+		if err := lspClient.DidOpen(fileURI, "go", string(before)); err != nil {
+			return nil, t.handleLSPError(err)
+		}
+
+		// gopls answers with a workspace/applyEdit request, which the client
+		// applies to the file
+		_, err = lspClient.ExecuteCommand("gopls.add_import", map[string]any{
+			"ImportPath": importPath,
+			"URI":        fileURI,
+		})
+		if err != nil {
+			return nil, t.handleLSPError(err)
+		}
+
+		after, err := os.ReadFile(filePath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read file: %w", err)
+		}
+
+		name := filepath.Base(filePath)
+		result := addImportResult{
+			ImportPath: importPath,
+			Changed:    string(before) != string(after),
+			Diff:       diff.Unified("a/"+name, "b/"+name, string(before), string(after)),
+		}
+
+		data, err := json.Marshal(result)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal result: %w", err)
+		}
+
+		return mcp.NewToolResultText(string(data)), nil
+	})
+}
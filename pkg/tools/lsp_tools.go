@@ -8,17 +8,28 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
 
 	"github.com/solatis/mcp-gopls/pkg/lsp/client"
+	"github.com/solatis/mcp-gopls/pkg/lsp/protocol"
 )
 
 type LSPTools struct {
-	client       client.LSPClient
-	clientGetter func() client.LSPClient
-	resetFunc    func(error) bool
+	client             client.LSPClient
+	clientGetter       func() client.LSPClient
+	resetFunc          func(error) bool
+	configClientGetter func(label string, settings map[string]any) (client.LSPClient, error)
+
+	// baselines are the diagnostics baselines kept in memory, per directory
+	baselinesMutex sync.Mutex
+	baselines      map[string]*diagnosticsBaseline
+
+	// optimizationMutex serializes optimization_details calls, which toggle
+	// the compiler details of a package on and off again
+	optimizationMutex sync.Mutex
 }
 
 func NewLSPTools(lspClient client.LSPClient) *LSPTools {
@@ -26,6 +37,7 @@ func NewLSPTools(lspClient client.LSPClient) *LSPTools {
 		client:       lspClient,
 		clientGetter: func() client.LSPClient { return lspClient },
 		resetFunc:    func(error) bool { return false },
+		baselines:    make(map[string]*diagnosticsBaseline),
 	}
 }
 
@@ -37,6 +49,12 @@ func (t *LSPTools) SetResetFunc(resetFunc func(error) bool) {
 	t.resetFunc = resetFunc
 }
 
+// SetConfigClientGetter sets the function providing gopls clients dedicated to
+// a build configuration
+func (t *LSPTools) SetConfigClientGetter(getter func(label string, settings map[string]any) (client.LSPClient, error)) {
+	t.configClientGetter = getter
+}
+
 func (t *LSPTools) getClient() client.LSPClient {
 	if t.clientGetter != nil {
 		return t.clientGetter()
@@ -58,10 +76,31 @@ func (t *LSPTools) handleLSPError(err error) error {
 func (t *LSPTools) Register(s *server.MCPServer) {
 	t.registerGoToDefinition(s)
 	t.registerFindReferences(s)
+	t.registerHover(s)
 	t.registerCheckDiagnostics(s)
 	t.registerDocumentSymbol(s)
 	t.registerWorkspaceSymbol(s)
 	t.registerListImplementations(s)
+	t.registerSetGoplsSettings(s)
+	t.registerGoModTools(s)
+	t.registerRunTests(s)
+	t.registerFindTests(s)
+	t.registerBuildCheck(s)
+	t.registerWorkspaceDiagnostics(s)
+	t.registerOptimizationDetails(s)
+	t.registerImportTools(s)
+	t.registerPackageAPI(s)
+	t.registerPackageGraph(s)
+	t.registerExtract(s)
+	t.registerImplementInterface(s)
+	t.registerFillStruct(s)
+	t.registerDeadCode(s)
+	t.registerApplyEdits(s)
+	t.registerCheckChanged(s)
+	t.registerDiagnosticsBaseline(s)
+	t.registerSymbolContext(s)
+	t.registerImpactAnalysis(s)
+	t.registerInterfaceSatisfaction(s)
 }
 
 func convertPathToURI(path string) string {
@@ -88,6 +127,20 @@ func convertPathToURI(path string) string {
 	return path
 }
 
+// resolveDir converts a path or file:// URI to a directory path. Paths of
+// files resolve to their directory.
+func resolveDir(pathOrURI string) string {
+	if !strings.HasPrefix(pathOrURI, "file://") {
+		pathOrURI = convertPathToURI(pathOrURI)
+	}
+
+	dir := protocol.URIToPath(pathOrURI)
+	if info, err := os.Stat(dir); err == nil && !info.IsDir() {
+		dir = filepath.Dir(dir)
+	}
+	return dir
+}
+
 func (t *LSPTools) registerGoToDefinition(s *server.MCPServer) {
 	definitionTool := mcp.NewTool("go_to_definition",
 		mcp.WithDescription("CRITICAL FOR CODE NAVIGATION: Use this LSP-powered tool instead of grep/search when you need to find where a function, type, variable, or interface is actually defined. This tool understands Go's type system and import paths, providing the EXACT location where a symbol is declared. Much faster and more accurate than text search. Use this when: 1) User asks 'where is X defined?', 2) You need to understand what a function/type actually does, 3) You're debugging and need to trace back to source definitions. Returns the file URI and exact line/character position of the definition."),
@@ -99,6 +152,7 @@ func (t *LSPTools) registerGoToDefinition(s *server.MCPServer) {
 			mcp.Required(),
 			mcp.Description("Position of the symbol to look up. Must contain 'line' (0-indexed line number) and 'character' (0-indexed column number) keys"),
 		),
+		contentOption(),
 	)
 
 	s.AddTool(definitionTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
@@ -107,20 +161,9 @@ func (t *LSPTools) registerGoToDefinition(s *server.MCPServer) {
 			return nil, errors.New("file_uri is required")
 		}
 
-		args := request.GetArguments()
-		positionObj, ok := args["position"].(map[string]any)
-		if !ok {
-			return nil, errors.New("position must be an object")
-		}
-
-		line, ok := positionObj["line"].(float64)
-		if !ok {
-			return nil, errors.New("line must be a number")
-		}
-
-		character, ok := positionObj["character"].(float64)
-		if !ok {
-			return nil, errors.New("character must be a number")
+		position, err := parsePosition(request.GetArguments()["position"])
+		if err != nil {
+			return nil, err
 		}
 
 		if !strings.HasPrefix(fileURI, "file://") {
@@ -132,7 +175,11 @@ func (t *LSPTools) registerGoToDefinition(s *server.MCPServer) {
 			return nil, errors.New("LSP client not available")
 		}
 
-		locations, err := lspClient.GoToDefinition(fileURI, int(line), int(character))
+		var locations []protocol.Location
+		err = withOverlay(lspClient, fileURI, contentArg(request), func() (err error) {
+			locations, err = lspClient.GoToDefinition(fileURI, position.Line, position.Character)
+			return err
+		})
 		if err != nil {
 			return nil, t.handleLSPError(err)
 		}
@@ -147,7 +194,7 @@ func (t *LSPTools) registerGoToDefinition(s *server.MCPServer) {
 }
 
 func (t *LSPTools) registerFindReferences(s *server.MCPServer) {
-	referencesTool := mcp.NewTool("find_references",
+	referencesTool := mcp.NewTool("find_references", withResultOptions(
 		mcp.WithDescription("ESSENTIAL FOR CODE IMPACT ANALYSIS: Use this LSP tool to instantly find ALL places where a function, type, method, or variable is used across the entire codebase. Dramatically faster and more accurate than grep because it understands Go's syntax, imports, and type system. Use this when: 1) User asks 'where is X used?', 2) Before modifying any function/type to understand impact, 3) Analyzing code dependencies and relationships, 4) Refactoring or renaming considerations. This tool saves significant time and context by providing a complete, accurate list of usages rather than requiring multiple file reads. Returns all locations with file URI and line/character positions."),
 		mcp.WithString("file_uri",
 			mcp.Required(),
@@ -157,7 +204,8 @@ func (t *LSPTools) registerFindReferences(s *server.MCPServer) {
 			mcp.Required(),
 			mcp.Description("Position of the symbol to find references for. Must contain 'line' (0-indexed line number) and 'character' (0-indexed column number) keys"),
 		),
-	)
+		contentOption(),
+	)...)
 
 	s.AddTool(referencesTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 		fileURI := request.GetString("file_uri", "")
@@ -165,32 +213,30 @@ func (t *LSPTools) registerFindReferences(s *server.MCPServer) {
 			return nil, errors.New("file_uri is required")
 		}
 
-		args := request.GetArguments()
-		positionObj, ok := args["position"].(map[string]any)
-		if !ok {
-			return nil, errors.New("position must be an object")
-		}
-
-		line, ok := positionObj["line"].(float64)
-		if !ok {
-			return nil, errors.New("line must be a number")
-		}
-
-		character, ok := positionObj["character"].(float64)
-		if !ok {
-			return nil, errors.New("character must be a number")
+		position, err := parsePosition(request.GetArguments()["position"])
+		if err != nil {
+			return nil, err
 		}
 
 		if !strings.HasPrefix(fileURI, "file://") {
 			fileURI = convertPathToURI(fileURI)
 		}
 
+		opts, err := parseResultOptions(request)
+		if err != nil {
+			return nil, err
+		}
+
 		lspClient := t.getClient()
 		if lspClient == nil {
 			return nil, errors.New("LSP client not available")
 		}
 
-		locations, err := lspClient.FindReferences(fileURI, int(line), int(character), true)
+		var locations []protocol.Location
+		err = withOverlay(lspClient, fileURI, contentArg(request), func() (err error) {
+			locations, err = lspClient.FindReferences(fileURI, position.Line, position.Character, true)
+			return err
+		})
 		if err != nil {
 			if strings.Contains(err.Error(), "client closed") {
 				return nil, fmt.Errorf("LSP client not available, please restart the server: %w", err)
@@ -198,7 +244,8 @@ func (t *LSPTools) registerFindReferences(s *server.MCPServer) {
 			return nil, t.handleLSPError(err)
 		}
 
-		result, err := json.Marshal(locations)
+		sortLocations(locations)
+		result, err := json.Marshal(paginate(locations, opts, locationURI))
 		if err != nil {
 			return nil, fmt.Errorf("failed to marshal result: %w", err)
 		}
@@ -209,11 +256,23 @@ func (t *LSPTools) registerFindReferences(s *server.MCPServer) {
 
 func (t *LSPTools) registerCheckDiagnostics(s *server.MCPServer) {
 	diagnosticsTool := mcp.NewTool("check_diagnostics",
-		mcp.WithDescription("INSTANT CODE VALIDATION: Use this LSP tool to immediately get all compile errors, type errors, and linting issues for a Go file WITHOUT running 'go build' or reading file contents. This is the fastest way to verify code correctness. Use this when: 1) After making any code changes to verify correctness, 2) User reports errors or asks 'why doesn't this compile?', 3) Before suggesting code fixes to understand current issues, 4) Debugging type mismatches or import problems. Returns a comprehensive list of all problems with exact locations and error messages. Much more efficient than running build commands or manually checking syntax."),
+		mcp.WithDescription("INSTANT CODE VALIDATION: Use this LSP tool to immediately get all compile errors, type errors, and linting issues for a Go file WITHOUT running 'go build' or reading file contents. This is the fastest way to verify code correctness. Use this when: 1) After making any code changes to verify correctness, 2) User reports errors or asks 'why doesn't this compile?', 3) Before suggesting code fixes to understand current issues, 4) Debugging type mismatches or import problems. Returns a comprehensive list of all problems with exact locations and error messages. Much more efficient than running build commands or manually checking syntax. Pass 'configurations' to also check other platforms (e.g. windows, js/wasm) or build tags; each diagnostic is then annotated with the configurations that reported it."),
 		mcp.WithString("file_uri",
 			mcp.Required(),
 			mcp.Description("URI or absolute path of the Go file to check. Can be a file:// URI or absolute path like /path/to/file.go"),
 		),
+		mcp.WithArray("configurations",
+			mcp.Description("Build configurations to check the file with, e.g. [{\"goos\": \"windows\", \"goarch\": \"amd64\"}, {\"goos\": \"js\", \"goarch\": \"wasm\"}, {\"tags\": [\"integration\"]}]. Each configuration is analyzed by a separate gopls instance"),
+			mcp.Items(map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"goos":   map[string]any{"type": "string"},
+					"goarch": map[string]any{"type": "string"},
+					"tags":   map[string]any{"type": "array", "items": map[string]any{"type": "string"}},
+				},
+			}),
+		),
+		contentOption(),
 	)
 
 	s.AddTool(diagnosticsTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
@@ -226,11 +285,36 @@ func (t *LSPTools) registerCheckDiagnostics(s *server.MCPServer) {
 			fileURI = convertPathToURI(fileURI)
 		}
 
-		if t.client == nil {
+		configs, err := parseBuildConfigs(request.GetArguments())
+		if err != nil {
+			return nil, err
+		}
+		content := contentArg(request)
+
+		if len(configs) > 0 {
+			diagnostics, err := t.diagnosticsForConfigs(fileURI, content, configs)
+			if err != nil {
+				return nil, t.handleLSPError(err)
+			}
+
+			result, err := json.Marshal(diagnostics)
+			if err != nil {
+				return nil, fmt.Errorf("failed to marshal result: %w", err)
+			}
+
+			return mcp.NewToolResultText(string(result)), nil
+		}
+
+		lspClient := t.getClient()
+		if lspClient == nil {
 			return nil, errors.New("LSP client not initialized")
 		}
 
-		diagnostics, err := t.client.GetDiagnostics(fileURI)
+		var diagnostics []protocol.Diagnostic
+		err = withOverlay(lspClient, fileURI, content, func() (err error) {
+			diagnostics, err = lspClient.GetDiagnostics(fileURI)
+			return err
+		})
 		if err != nil {
 			if strings.Contains(err.Error(), "client closed") {
 				return nil, fmt.Errorf("LSP service not available, please restart the server: %w", err)
@@ -248,13 +332,16 @@ func (t *LSPTools) registerCheckDiagnostics(s *server.MCPServer) {
 }
 
 func (t *LSPTools) registerDocumentSymbol(s *server.MCPServer) {
-	documentSymbolTool := mcp.NewTool("document_symbol",
-		mcp.WithDescription("FILE STRUCTURE AT A GLANCE: Use this LSP tool to instantly get a complete hierarchical outline of ALL symbols (functions, types, methods, variables, constants) in a Go file. This is 10-100x faster than reading the entire file and gives you immediate understanding of code structure. Use this when: 1) User asks 'what's in this file?' or 'show me the structure', 2) You need to understand a file's organization before making changes, 3) Looking for specific functions/types in a file, 4) Analyzing code architecture. Returns a tree structure with symbol names, types, and exact locations. Saves massive amounts of context compared to reading entire files."),
+	toolOptions := []mcp.ToolOption{
+		mcp.WithDescription("FILE STRUCTURE AT A GLANCE: Use this LSP tool to instantly get a complete hierarchical outline of ALL symbols (functions, types, methods, variables, constants) in a Go file. This is 10-100x faster than reading the entire file and gives you immediate understanding of code structure. Use this when: 1) User asks 'what's in this file?' or 'show me the structure', 2) You need to understand a file's organization before making changes, 3) Looking for specific functions/types in a file, 4) Analyzing code architecture. Returns a tree structure with symbol names, types, signatures and exact locations, or a flat/names-only list for big files. Saves massive amounts of context compared to reading entire files."),
 		mcp.WithString("file_uri",
 			mcp.Required(),
 			mcp.Description("URI or absolute path of the Go file to analyze. Can be a file:// URI or absolute path like /path/to/file.go"),
 		),
-	)
+		contentOption(),
+	}
+	toolOptions = append(toolOptions, outlineToolOptions()...)
+	documentSymbolTool := mcp.NewTool("document_symbol", toolOptions...)
 
 	s.AddTool(documentSymbolTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 		fileURI := request.GetString("file_uri", "")
@@ -266,17 +353,26 @@ func (t *LSPTools) registerDocumentSymbol(s *server.MCPServer) {
 			fileURI = convertPathToURI(fileURI)
 		}
 
+		opts, err := parseOutlineOptions(request)
+		if err != nil {
+			return nil, err
+		}
+
 		lspClient := t.getClient()
 		if lspClient == nil {
 			return nil, errors.New("LSP client not available")
 		}
 
-		symbols, err := lspClient.GetDocumentSymbols(fileURI)
+		var symbols []protocol.DocumentSymbol
+		err = withOverlay(lspClient, fileURI, contentArg(request), func() (err error) {
+			symbols, err = lspClient.GetDocumentSymbols(fileURI)
+			return err
+		})
 		if err != nil {
 			return nil, t.handleLSPError(err)
 		}
 
-		result, err := json.Marshal(symbols)
+		result, err := json.Marshal(renderOutline(symbols, opts))
 		if err != nil {
 			return nil, fmt.Errorf("failed to marshal result: %w", err)
 		}
@@ -286,13 +382,15 @@ func (t *LSPTools) registerDocumentSymbol(s *server.MCPServer) {
 }
 
 func (t *LSPTools) registerWorkspaceSymbol(s *server.MCPServer) {
-	workspaceSymbolTool := mcp.NewTool("workspace_symbol",
-		mcp.WithDescription("PROJECT-WIDE SYMBOL SEARCH: Use this LSP tool to search for any symbol (function, type, interface, method, constant) across the ENTIRE workspace/project instantly. Far superior to grep because it understands Go syntax and only returns actual symbol definitions, not comments or string matches. Use this when: 1) User asks 'where is type X defined in the project?', 2) You need to find a function but don't know which file, 3) Exploring unfamiliar codebases, 4) Understanding project structure and dependencies. Supports fuzzy matching (e.g., 'htpSrv' finds 'httpServer'). Returns symbol names, types, and exact file locations."),
+	toolOptions := []mcp.ToolOption{
+		mcp.WithDescription("PROJECT-WIDE SYMBOL SEARCH: Use this LSP tool to search for any symbol (function, type, interface, method, constant) across the ENTIRE workspace/project instantly. Far superior to grep because it understands Go syntax and only returns actual symbol definitions, not comments or string matches. Use this when: 1) User asks 'where is type X defined in the project?', 2) You need to find a function but don't know which file, 3) Exploring unfamiliar codebases, 4) Understanding project structure and dependencies. Supports fuzzy matching (e.g., 'htpSrv' finds 'httpServer'). Can be narrowed down by symbol kind, package, exported-only and test/vendor exclusion. Returns symbol names, types, and exact file locations."),
 		mcp.WithString("query",
 			mcp.Required(),
 			mcp.Description("Symbol name to search for. Supports partial and fuzzy matching. Examples: 'Server' finds all symbols with Server in name, 'hndlr' might find 'handler', 'Handler', etc."),
 		),
-	)
+	}
+	toolOptions = append(toolOptions, symbolFilterOptions()...)
+	workspaceSymbolTool := mcp.NewTool("workspace_symbol", withResultOptions(toolOptions...)...)
 
 	s.AddTool(workspaceSymbolTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 		query := request.GetString("query", "")
@@ -300,17 +398,50 @@ func (t *LSPTools) registerWorkspaceSymbol(s *server.MCPServer) {
 			return nil, errors.New("query is required")
 		}
 
+		opts, err := parseResultOptions(request)
+		if err != nil {
+			return nil, err
+		}
+
+		kinds, err := parseSymbolKinds(request.GetStringSlice("kinds", nil))
+		if err != nil {
+			return nil, err
+		}
+
+		filter := symbolFilter{
+			Kinds:         kinds,
+			PackagePrefix: request.GetString("package_prefix", ""),
+			ExportedOnly:  request.GetBool("exported_only", false),
+			ExcludeTests:  request.GetBool("exclude_tests", false),
+			ExcludeVendor: request.GetBool("exclude_vendor", false),
+		}
+
+		// The matcher and scope are gopls settings, applied to this query only
+		settings := map[string]any{}
+		if matcher := request.GetString("symbol_matcher", ""); matcher != "" {
+			settings["symbolMatcher"] = matcher
+		}
+		if scope := request.GetString("symbol_scope", ""); scope != "" {
+			settings["symbolScope"] = scope
+		}
+
 		lspClient := t.getClient()
 		if lspClient == nil {
 			return nil, errors.New("LSP client not available")
 		}
 
-		symbols, err := lspClient.GetWorkspaceSymbols(query)
+		symbols, err := lspClient.GetWorkspaceSymbolsWithSettings(query, settings)
 		if err != nil {
 			return nil, t.handleLSPError(err)
 		}
 
-		result, err := json.Marshal(symbols)
+		symbols = filter.apply(symbols)
+		// gopls ranks the matches by relevance, which pages keep unless the
+		// results are grouped by file or package
+		if opts.GroupBy != groupByNone {
+			sortSymbols(symbols)
+		}
+		result, err := json.Marshal(paginate(symbols, opts, symbolURI))
 		if err != nil {
 			return nil, fmt.Errorf("failed to marshal result: %w", err)
 		}
@@ -320,7 +451,7 @@ func (t *LSPTools) registerWorkspaceSymbol(s *server.MCPServer) {
 }
 
 func (t *LSPTools) registerListImplementations(s *server.MCPServer) {
-	implementationsTool := mcp.NewTool("list_interface_implementation",
+	implementationsTool := mcp.NewTool("list_interface_implementation", withResultOptions(
 		mcp.WithDescription("FIND ALL IMPLEMENTATIONS: Use this LSP tool to instantly find ALL types that implement a specific interface, or find the interface that a method implements. Critical for understanding Go's interface-based design. Use this when: 1) User asks 'what implements interface X?', 2) Understanding which concrete types satisfy an interface, 3) Before modifying interfaces to see impact, 4) Exploring polymorphic code behavior, 5) Finding all handlers/plugins that implement a pattern. Much more accurate than text search as it understands Go's type system. Returns exact locations of all implementing types."),
 		mcp.WithString("file_uri",
 			mcp.Required(),
@@ -330,7 +461,8 @@ func (t *LSPTools) registerListImplementations(s *server.MCPServer) {
 			mcp.Required(),
 			mcp.Description("Position of the interface name or method to find implementations for. Must contain 'line' (0-indexed line number) and 'character' (0-indexed column number) keys"),
 		),
-	)
+		contentOption(),
+	)...)
 
 	s.AddTool(implementationsTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 		fileURI := request.GetString("file_uri", "")
@@ -338,37 +470,36 @@ func (t *LSPTools) registerListImplementations(s *server.MCPServer) {
 			return nil, errors.New("file_uri is required")
 		}
 
-		args := request.GetArguments()
-		positionObj, ok := args["position"].(map[string]any)
-		if !ok {
-			return nil, errors.New("position must be an object")
-		}
-
-		line, ok := positionObj["line"].(float64)
-		if !ok {
-			return nil, errors.New("line must be a number")
-		}
-
-		character, ok := positionObj["character"].(float64)
-		if !ok {
-			return nil, errors.New("character must be a number")
+		position, err := parsePosition(request.GetArguments()["position"])
+		if err != nil {
+			return nil, err
 		}
 
 		if !strings.HasPrefix(fileURI, "file://") {
 			fileURI = convertPathToURI(fileURI)
 		}
 
+		opts, err := parseResultOptions(request)
+		if err != nil {
+			return nil, err
+		}
+
 		lspClient := t.getClient()
 		if lspClient == nil {
 			return nil, errors.New("LSP client not available")
 		}
 
-		locations, err := lspClient.GetImplementations(fileURI, int(line), int(character))
+		var locations []protocol.Location
+		err = withOverlay(lspClient, fileURI, contentArg(request), func() (err error) {
+			locations, err = lspClient.GetImplementations(fileURI, position.Line, position.Character)
+			return err
+		})
 		if err != nil {
 			return nil, t.handleLSPError(err)
 		}
 
-		result, err := json.Marshal(locations)
+		sortLocations(locations)
+		result, err := json.Marshal(paginate(locations, opts, locationURI))
 		if err != nil {
 			return nil, fmt.Errorf("failed to marshal result: %w", err)
 		}
@@ -0,0 +1,311 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/solatis/mcp-gopls/pkg/lsp/client"
+	"github.com/solatis/mcp-gopls/pkg/lsp/protocol"
+)
+
+const (
+	optimizationEscape = "escape"
+	optimizationInline = "inline"
+	optimizationBounds = "bounds"
+	optimizationNil    = "nil"
+	optimizationOther  = "other"
+
+	// optimizationDetailsTimeout bounds the wait for gopls to compile the
+	// package with optimization details
+	optimizationDetailsTimeout = 60 * time.Second
+)
+
+// optimizationDetail is a single compiler optimization decision
+type optimizationDetail struct {
+	Line     int    `json:"line"`
+	Category string `json:"category"`
+	Message  string `json:"message"`
+}
+
+// functionOptimizations holds the optimization decisions within a function
+type functionOptimizations struct {
+	Function string               `json:"function"`
+	File     string               `json:"file"`
+	Line     int                  `json:"line"`
+	Counts   map[string]int       `json:"counts"`
+	Details  []optimizationDetail `json:"details"`
+}
+
+type optimizationResult struct {
+	Dir       string                  `json:"dir"`
+	Total     int                     `json:"total"`
+	Counts    map[string]int          `json:"counts"`
+	Functions []functionOptimizations `json:"functions"`
+}
+
+// optimizationCategory classifies a compiler optimization message
+func optimizationCategory(message string) string {
+	switch {
+	case strings.Contains(message, "escape"), strings.Contains(message, "moved to heap"), strings.Contains(message, "leaking param"):
+		return optimizationEscape
+	case strings.Contains(message, "inlin"):
+		return optimizationInline
+	case strings.Contains(message, "InBounds"):
+		return optimizationBounds
+	case strings.Contains(message, "nilcheck"):
+		return optimizationNil
+	default:
+		return optimizationOther
+	}
+}
+
+// isOptimizationDetail reports whether a diagnostic was produced by the gopls
+// compiler optimization details, whose source is "optimizer details" or
+// "compiler optimization details" depending on the gopls version
+func isOptimizationDetail(d protocol.Diagnostic) bool {
+	return strings.Contains(strings.ToLower(d.Source), "optimi")
+}
+
+// matchFunctionName reports whether a document symbol name, such as "Parse" or
+// "(*Parser).Next", is the requested function. Methods can be requested as
+// "Next", "Parser.Next" or "(*Parser).Next".
+func matchFunctionName(symbolName, name string) bool {
+	if symbolName == name {
+		return true
+	}
+
+	receiver, method, ok := strings.Cut(symbolName, ").")
+	if !ok {
+		return false
+	}
+	receiver = strings.TrimLeft(receiver, "(*")
+	return name == method || name == receiver+"."+method
+}
+
+func (t *LSPTools) registerOptimizationDetails(s *server.MCPServer) {
+	optimizationTool := mcp.NewTool("optimization_details",
+		mcp.WithDescription("COMPILER OPTIMIZATION DECISIONS: Use this tool for performance work instead of running 'go build -gcflags=-m' by hand. Reports, per function, what the compiler decided for a package: variables escaping to the heap, inlined and non-inlinable calls, remaining bounds checks and nil checks. Use this when: 1) Optimizing a hot path, 2) Checking why a function allocates, 3) Verifying a function gets inlined. Can be filtered to a single function or method."),
+		mcp.WithString("dir",
+			mcp.Required(),
+			mcp.Description("Package directory (or a file in it) to analyze, as a path or file:// URI"),
+		),
+		mcp.WithString("symbol",
+			mcp.Description("Only report this function or method, e.g. 'Parse', 'Next' or 'Parser.Next'"),
+		),
+		mcp.WithArray("categories",
+			mcp.Description("Only report these kinds of decisions (default all)"),
+			mcp.Items(map[string]any{
+				"type": "string",
+				"enum": []string{optimizationEscape, optimizationInline, optimizationBounds, optimizationNil, optimizationOther},
+			}),
+		),
+	)
+
+	s.AddTool(optimizationTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		dir := request.GetString("dir", "")
+		if dir == "" {
+			return nil, errors.New("dir is required")
+		}
+		dir = resolveDir(dir)
+		symbol := request.GetString("symbol", "")
+
+		categories := make(map[string]bool)
+		for _, category := range request.GetStringSlice("categories", nil) {
+			categories[category] = true
+		}
+
+		lspClient := t.getClient()
+		if lspClient == nil {
+			return nil, errors.New("LSP client not available")
+		}
+
+		t.optimizationMutex.Lock()
+		details, err := collectOptimizationDetails(lspClient, dir)
+		t.optimizationMutex.Unlock()
+		if err != nil {
+			return nil, t.handleLSPError(err)
+		}
+
+		result := optimizationResult{
+			Dir:       dir,
+			Counts:    make(map[string]int),
+			Functions: []functionOptimizations{},
+		}
+
+		cache := newSymbolCache(lspClient)
+		index := make(map[string]int)
+
+		uris := make([]string, 0, len(details))
+		for uri := range details {
+			uris = append(uris, uri)
+		}
+		sort.Strings(uris)
+
+		for _, uri := range uris {
+			diagnostics := details[uri]
+			sort.SliceStable(diagnostics, func(i, j int) bool {
+				return diagnostics[i].Range.Start.Line < diagnostics[j].Range.Start.Line
+			})
+
+			for _, d := range diagnostics {
+				category := optimizationCategory(d.Message)
+				if len(categories) > 0 && !categories[category] {
+					continue
+				}
+
+				enclosing, err := cache.enclosing(uri, d.Range.Start)
+				if err != nil {
+					return nil, t.handleLSPError(err)
+				}
+
+				function := "(package level)"
+				line := 0
+				if enclosing != nil {
+					function = enclosing.Name
+					line = enclosing.SelectionRange.Start.Line
+				}
+				if symbol != "" && !matchFunctionName(function, symbol) {
+					continue
+				}
+
+				key := uri + "#" + function
+				i, ok := index[key]
+				if !ok {
+					i = len(result.Functions)
+					index[key] = i
+					result.Functions = append(result.Functions, functionOptimizations{
+						Function: function,
+						File:     protocol.URIToPath(uri),
+						Line:     line,
+						Counts:   make(map[string]int),
+					})
+				}
+
+				entry := &result.Functions[i]
+				entry.Counts[category]++
+				entry.Details = append(entry.Details, optimizationDetail{
+					Line:     d.Range.Start.Line,
+					Category: category,
+					Message:  d.Message,
+				})
+				result.Counts[category]++
+				result.Total++
+			}
+		}
+
+		data, err := json.Marshal(result)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal result: %w", err)
+		}
+
+		return mcp.NewToolResultText(string(data)), nil
+	})
+}
+
+// collectOptimizationDetails turns on the gopls compiler optimization details
+// for the package in dir, waits for them to be published and turns them off
+// again if they were off. The details are returned per file URI. Calls must
+// not overlap, or one could turn off the details another just turned on.
+func collectOptimizationDetails(lspClient client.LSPClient, dir string) (map[string][]protocol.Diagnostic, error) {
+	goFile := packageGoFile(dir)
+	if goFile == "" {
+		return nil, fmt.Errorf("no Go files in %s", dir)
+	}
+	uri := convertPathToURI(goFile)
+
+	// Open a file of the package so that gopls loads it
+	if _, err := lspClient.GetDiagnostics(uri); err != nil {
+		return nil, err
+	}
+
+	// The gopls command toggles the details, so only switch them on when the
+	// package does not have them yet
+	details := optimizationDetailsIn(lspClient, dir)
+	if len(details) == 0 {
+		if err := toggleOptimizationDetails(lspClient, uri); err != nil {
+			return nil, err
+		}
+		defer func() {
+			if err := toggleOptimizationDetails(lspClient, uri); err != nil {
+				log.Printf("⚠️ Failed to turn optimization details off for %s: %v", dir, err)
+			}
+		}()
+
+		deadline := time.After(optimizationDetailsTimeout)
+		for {
+			updated := lspClient.DiagnosticsUpdated()
+			if details = optimizationDetailsIn(lspClient, dir); len(details) > 0 {
+				break
+			}
+			select {
+			case <-updated:
+			case <-deadline:
+				return nil, fmt.Errorf("gopls published no optimization details for %s within %v", dir, optimizationDetailsTimeout)
+			}
+		}
+
+		// Details of the files of a package are published one by one
+		lspClient.WaitForDiagnosticsIdle(diagnosticsQuietPeriod, optimizationDetailsTimeout)
+		details = optimizationDetailsIn(lspClient, dir)
+	}
+
+	return details, nil
+}
+
+// toggleOptimizationDetails runs the gopls command toggling the optimization
+// details of the package containing uri. gopls v0.17 renamed gopls.gc_details
+// to gopls.toggle_compiler_opt_details.
+func toggleOptimizationDetails(lspClient client.LSPClient, uri string) error {
+	_, err := lspClient.ExecuteCommand("gopls.toggle_compiler_opt_details", map[string]any{"URI": uri})
+	if err == nil {
+		return nil
+	}
+
+	if _, legacyErr := lspClient.ExecuteCommand("gopls.gc_details", uri); legacyErr != nil {
+		return fmt.Errorf("failed to toggle optimization details: %w", err)
+	}
+	return nil
+}
+
+// optimizationDetailsIn returns the published optimization details of the
+// files directly in dir
+func optimizationDetailsIn(lspClient client.LSPClient, dir string) map[string][]protocol.Diagnostic {
+	details := make(map[string][]protocol.Diagnostic)
+	for uri, diagnostics := range lspClient.WorkspaceDiagnostics() {
+		if filepath.Dir(protocol.URIToPath(uri)) != dir {
+			continue
+		}
+		for _, d := range diagnostics {
+			if isOptimizationDetail(d) {
+				details[uri] = append(details[uri], d)
+			}
+		}
+	}
+	return details
+}
+
+// packageGoFile returns a non-test Go file directly in dir, or ""
+func packageGoFile(dir string) string {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return ""
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if !entry.IsDir() && strings.HasSuffix(name, ".go") && !strings.HasSuffix(name, "_test.go") {
+			return filepath.Join(dir, name)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,149 @@
+package tools
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/solatis/mcp-gopls/pkg/lsp/protocol"
+)
+
+const (
+	outlineFormatTree  = "tree"
+	outlineFormatFlat  = "flat"
+	outlineFormatNames = "names"
+)
+
+// outlineOptions controls how the document symbol tree is rendered.
+type outlineOptions struct {
+	MaxDepth      int
+	Kinds         map[protocol.SymbolKind]bool
+	Format        string
+	IncludeDetail bool
+}
+
+// flatSymbol is a document symbol with its qualified name, as returned in the
+// flat outline format.
+type flatSymbol struct {
+	Name    string `json:"name"`
+	Kind    string `json:"kind"`
+	Detail  string `json:"detail,omitempty"`
+	Line    int    `json:"line"`
+	EndLine int    `json:"end_line"`
+}
+
+func outlineToolOptions() []mcp.ToolOption {
+	return []mcp.ToolOption{
+		mcp.WithNumber("max_depth",
+			mcp.Description("Maximum depth of the outline, 1 returns only top-level symbols (default 0, unlimited)"),
+			mcp.Min(0),
+		),
+		mcp.WithArray("kinds",
+			mcp.Description("Only return symbols of these kinds, e.g. [\"function\", \"method\", \"struct\"]. In tree format, parents of matching symbols are kept"),
+			mcp.Items(map[string]any{"type": "string"}),
+		),
+		mcp.WithString("format",
+			mcp.Description("'tree' returns the full symbol hierarchy with ranges, 'flat' a list with qualified names like 'Type.Field' and line numbers, 'names' one compact line per symbol"),
+			mcp.Enum(outlineFormatTree, outlineFormatFlat, outlineFormatNames),
+		),
+		mcp.WithBoolean("include_detail",
+			mcp.Description("Include symbol details such as function signatures and field types (default true)"),
+		),
+	}
+}
+
+func parseOutlineOptions(request mcp.CallToolRequest) (outlineOptions, error) {
+	opts := outlineOptions{
+		MaxDepth:      request.GetInt("max_depth", 0),
+		Format:        request.GetString("format", outlineFormatTree),
+		IncludeDetail: request.GetBool("include_detail", true),
+	}
+
+	if opts.MaxDepth < 0 {
+		return opts, fmt.Errorf("max_depth must not be negative")
+	}
+
+	switch opts.Format {
+	case outlineFormatTree, outlineFormatFlat, outlineFormatNames:
+	default:
+		return opts, fmt.Errorf("unsupported format: %s", opts.Format)
+	}
+
+	kinds, err := parseSymbolKinds(request.GetStringSlice("kinds", nil))
+	if err != nil {
+		return opts, err
+	}
+	opts.Kinds = kinds
+
+	return opts, nil
+}
+
+// renderOutline converts document symbols to the requested outline format.
+func renderOutline(symbols []protocol.DocumentSymbol, opts outlineOptions) any {
+	switch opts.Format {
+	case outlineFormatFlat:
+		return flattenSymbols(symbols, opts, "", 1, nil)
+	case outlineFormatNames:
+		flat := flattenSymbols(symbols, opts, "", 1, nil)
+		names := make([]string, len(flat))
+		for i, symbol := range flat {
+			names[i] = fmt.Sprintf("%s %s", symbol.Kind, symbol.Name)
+			if symbol.Detail != "" {
+				names[i] += " " + symbol.Detail
+			}
+		}
+		return names
+	default:
+		return pruneSymbols(symbols, opts, 1)
+	}
+}
+
+func pruneSymbols(symbols []protocol.DocumentSymbol, opts outlineOptions, depth int) []protocol.DocumentSymbol {
+	pruned := make([]protocol.DocumentSymbol, 0, len(symbols))
+	for _, symbol := range symbols {
+		var children []protocol.DocumentSymbol
+		if opts.MaxDepth == 0 || depth < opts.MaxDepth {
+			children = pruneSymbols(symbol.Children, opts, depth+1)
+		}
+
+		if len(opts.Kinds) > 0 && !opts.Kinds[symbol.Kind] && len(children) == 0 {
+			continue
+		}
+
+		symbol.Children = children
+		if !opts.IncludeDetail {
+			symbol.Detail = ""
+		}
+		pruned = append(pruned, symbol)
+	}
+	return pruned
+}
+
+func flattenSymbols(symbols []protocol.DocumentSymbol, opts outlineOptions, prefix string, depth int, flat []flatSymbol) []flatSymbol {
+	for _, symbol := range symbols {
+		// Methods are already qualified by their receiver, as in "(*T).M"
+		name := symbol.Name
+		if prefix != "" && !strings.HasPrefix(name, "(") {
+			name = prefix + "." + name
+		}
+
+		if len(opts.Kinds) == 0 || opts.Kinds[symbol.Kind] {
+			entry := flatSymbol{
+				Name:    name,
+				Kind:    symbol.Kind.String(),
+				Line:    symbol.SelectionRange.Start.Line,
+				EndLine: symbol.Range.End.Line,
+			}
+			if opts.IncludeDetail {
+				entry.Detail = strings.TrimSpace(symbol.Detail)
+			}
+			flat = append(flat, entry)
+		}
+
+		if opts.MaxDepth == 0 || depth < opts.MaxDepth {
+			flat = flattenSymbols(symbol.Children, opts, name, depth+1, flat)
+		}
+	}
+	return flat
+}
@@ -0,0 +1,125 @@
+package tools
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/solatis/mcp-gopls/pkg/lsp/protocol"
+)
+
+// outlineSymbols is the outline of a file with a struct, one of its methods
+// named with its receiver as gopls does, and a function
+func outlineSymbols() []protocol.DocumentSymbol {
+	at := func(start, end int) protocol.Range {
+		return protocol.Range{Start: protocol.Position{Line: start}, End: protocol.Position{Line: end}}
+	}
+	return []protocol.DocumentSymbol{
+		{
+			Name: "Server", Kind: protocol.SKStruct, Detail: "struct{...}", Range: at(2, 5), SelectionRange: at(2, 2),
+			Children: []protocol.DocumentSymbol{
+				{Name: "addr", Kind: protocol.SKField, Detail: "string", Range: at(3, 3), SelectionRange: at(3, 3)},
+				{Name: "(*Server).Start", Kind: protocol.SKMethod, Detail: "func() error", Range: at(7, 9), SelectionRange: at(7, 7)},
+			},
+		},
+		{Name: "main", Kind: protocol.SKFunction, Detail: "func()", Range: at(11, 13), SelectionRange: at(11, 11)},
+	}
+}
+
+func TestFlattenSymbols(t *testing.T) {
+	tests := []struct {
+		name string
+		opts outlineOptions
+		want []flatSymbol
+	}{
+		{
+			name: "qualified names",
+			opts: outlineOptions{IncludeDetail: true},
+			want: []flatSymbol{
+				{Name: "Server", Kind: "struct", Detail: "struct{...}", Line: 2, EndLine: 5},
+				{Name: "Server.addr", Kind: "field", Detail: "string", Line: 3, EndLine: 3},
+				{Name: "(*Server).Start", Kind: "method", Detail: "func() error", Line: 7, EndLine: 9},
+				{Name: "main", Kind: "function", Detail: "func()", Line: 11, EndLine: 13},
+			},
+		},
+		{
+			name: "max depth without detail",
+			opts: outlineOptions{MaxDepth: 1},
+			want: []flatSymbol{
+				{Name: "Server", Kind: "struct", Line: 2, EndLine: 5},
+				{Name: "main", Kind: "function", Line: 11, EndLine: 13},
+			},
+		},
+		{
+			name: "kinds",
+			opts: outlineOptions{Kinds: map[protocol.SymbolKind]bool{protocol.SKMethod: true}},
+			want: []flatSymbol{
+				{Name: "(*Server).Start", Kind: "method", Line: 7, EndLine: 9},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := flattenSymbols(outlineSymbols(), tt.opts, "", 1, nil); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("flattenSymbols() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPruneSymbols(t *testing.T) {
+	names := func(symbols []protocol.DocumentSymbol) []string {
+		var result []string
+		var walk func(symbols []protocol.DocumentSymbol, depth string)
+		walk = func(symbols []protocol.DocumentSymbol, depth string) {
+			for _, symbol := range symbols {
+				result = append(result, depth+symbol.Name)
+				walk(symbol.Children, depth+"  ")
+			}
+		}
+		walk(symbols, "")
+		return result
+	}
+
+	tests := []struct {
+		name string
+		opts outlineOptions
+		want []string
+	}{
+		{"full tree", outlineOptions{}, []string{"Server", "  addr", "  (*Server).Start", "main"}},
+		{"max depth", outlineOptions{MaxDepth: 1}, []string{"Server", "main"}},
+		{
+			name: "parents of matching kinds kept",
+			opts: outlineOptions{Kinds: map[protocol.SymbolKind]bool{protocol.SKField: true}},
+			want: []string{"Server", "  addr"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := names(pruneSymbols(outlineSymbols(), tt.opts, 1)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("pruneSymbols() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	for _, symbol := range pruneSymbols(outlineSymbols(), outlineOptions{}, 1) {
+		if symbol.Detail != "" {
+			t.Errorf("pruneSymbols() kept detail %q of %s without include_detail", symbol.Detail, symbol.Name)
+		}
+	}
+}
+
+func TestRenderOutlineNames(t *testing.T) {
+	opts := outlineOptions{Format: outlineFormatNames, IncludeDetail: true}
+	want := []string{
+		"struct Server struct{...}",
+		"field Server.addr string",
+		"method (*Server).Start func() error",
+		"function main func()",
+	}
+
+	if got := renderOutline(outlineSymbols(), opts); !reflect.DeepEqual(got, want) {
+		t.Errorf("renderOutline() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,45 @@
+package tools
+
+import (
+	"log"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/solatis/mcp-gopls/pkg/lsp/client"
+)
+
+// contentOption is the tool option for analyzing unsaved file content
+func contentOption() mcp.ToolOption {
+	return mcp.WithString("content",
+		mcp.Description("Unsaved content of the file to analyze instead of its content on disk, e.g. a candidate implementation. It is sent to gopls as an in-memory overlay, the file on disk is not touched, and gopls reverts to the disk content afterwards"),
+	)
+}
+
+// contentArg returns the content argument of a request, or nil when it is not
+// given. Empty content is analyzed as an empty file.
+func contentArg(request mcp.CallToolRequest) *string {
+	content, ok := request.GetArguments()["content"].(string)
+	if !ok {
+		return nil
+	}
+	return &content
+}
+
+// withOverlay runs fn with content as an in-memory overlay of the document.
+// Without content, fn runs against the document on disk.
+func withOverlay(lspClient client.LSPClient, uri string, content *string, fn func() error) error {
+	if content == nil {
+		return fn()
+	}
+
+	if err := lspClient.SetOverlay(uri, *content); err != nil {
+		return err
+	}
+	defer func() {
+		if err := lspClient.ClearOverlay(uri); err != nil {
+			log.Printf("⚠️ Failed to clear overlay of %s: %v", uri, err)
+		}
+	}()
+
+	return fn()
+}
@@ -0,0 +1,78 @@
+package tools
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/solatis/mcp-gopls/pkg/lsp/client"
+)
+
+// overlayRecorder records the overlay calls made on a client
+type overlayRecorder struct {
+	client.LSPClient
+	calls []string
+}
+
+func (r *overlayRecorder) SetOverlay(uri, text string) error {
+	r.calls = append(r.calls, "set "+uri+" "+text)
+	return nil
+}
+
+func (r *overlayRecorder) ClearOverlay(uri string) error {
+	r.calls = append(r.calls, "clear "+uri)
+	return nil
+}
+
+func TestContentArg(t *testing.T) {
+	request := func(args map[string]any) mcp.CallToolRequest {
+		var r mcp.CallToolRequest
+		r.Params.Arguments = args
+		return r
+	}
+
+	if content := contentArg(request(map[string]any{"file_uri": "file:///a.go"})); content != nil {
+		t.Errorf("contentArg without content = %q, want nil", *content)
+	}
+	if content := contentArg(request(map[string]any{"content": ""})); content == nil || *content != "" {
+		t.Errorf("contentArg with empty content = %v, want empty string", content)
+	}
+	if content := contentArg(request(map[string]any{"content": "package a"})); content == nil || *content != "package a" {
+		t.Errorf("contentArg = %v, want \"package a\"", content)
+	}
+}
+
+func TestWithOverlay(t *testing.T) {
+	empty, code := "", "package a"
+	fnErr := errors.New("failed")
+
+	tests := []struct {
+		name    string
+		content *string
+		err     error
+		want    []string
+	}{
+		{"disk", nil, nil, []string{"fn"}},
+		{"empty content", &empty, nil, []string{"set file:///a.go ", "fn", "clear file:///a.go"}},
+		{"content", &code, nil, []string{"set file:///a.go package a", "fn", "clear file:///a.go"}},
+		{"cleared on error", &code, fnErr, []string{"set file:///a.go package a", "fn", "clear file:///a.go"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := &overlayRecorder{}
+			err := withOverlay(recorder, "file:///a.go", tt.content, func() error {
+				recorder.calls = append(recorder.calls, "fn")
+				return tt.err
+			})
+			if !errors.Is(err, tt.err) {
+				t.Errorf("withOverlay error = %v, want %v", err, tt.err)
+			}
+			if !reflect.DeepEqual(recorder.calls, tt.want) {
+				t.Errorf("calls = %q, want %q", recorder.calls, tt.want)
+			}
+		})
+	}
+}
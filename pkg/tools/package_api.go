@@ -0,0 +1,69 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/solatis/mcp-gopls/pkg/apidoc"
+)
+
+func (t *LSPTools) registerPackageAPI(s *server.MCPServer) {
+	packageAPITool := mcp.NewTool("package_api",
+		mcp.WithDescription("PACKAGE API AT A GLANCE: Use this tool instead of reading the files of a package to learn how to use it. For an import path, returns the exported surface: types with their declarations and method sets, functions with signatures, constants and variables, each with the first sentence of its doc comment. Works for workspace packages, the standard library and module dependencies. Use this when: 1) Calling into a dependency or sibling package, 2) Checking what a package offers before writing code against it."),
+		mcp.WithString("import_path",
+			mcp.Required(),
+			mcp.Description("Import path of the package, e.g. 'net/http' or 'github.com/mark3labs/mcp-go/mcp', or a relative directory like './pkg/diff'"),
+		),
+		mcp.WithString("dir",
+			mcp.Description("Directory of the module to resolve the import path from, as a path or file:// URI (default: the server's working directory)"),
+		),
+	)
+
+	s.AddTool(packageAPITool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		importPath := request.GetString("import_path", "")
+		if importPath == "" {
+			return nil, errors.New("import_path is required")
+		}
+
+		dir := request.GetString("dir", "")
+		if dir != "" {
+			dir = resolveDir(dir)
+		} else {
+			wd, err := os.Getwd()
+			if err != nil {
+				return nil, fmt.Errorf("failed to get working directory: %w", err)
+			}
+			dir = wd
+		}
+
+		packages, err := t.goRunner().ListPackages(ctx, dir, false, importPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to find package: %w", err)
+		}
+		if len(packages) != 1 {
+			return nil, fmt.Errorf("import path %s matches %d packages, expected one", importPath, len(packages))
+		}
+		pkg := packages[0]
+		if pkg.Error != nil && len(pkg.GoFiles) == 0 {
+			return nil, fmt.Errorf("failed to load package %s: %s", importPath, pkg.Error.Err)
+		}
+
+		api, err := apidoc.Extract(pkg.ImportPath, pkg.Dir, pkg.GoFiles)
+		if err != nil {
+			return nil, err
+		}
+
+		result, err := json.Marshal(api)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal result: %w", err)
+		}
+
+		return mcp.NewToolResultText(string(result)), nil
+	})
+}
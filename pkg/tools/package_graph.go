@@ -0,0 +1,349 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/solatis/mcp-gopls/pkg/gocmd"
+)
+
+const (
+	packageKindWorkspace  = "workspace"
+	packageKindStdlib     = "stdlib"
+	packageKindThirdParty = "third_party"
+
+	graphFormatJSON    = "json"
+	graphFormatDOT     = "dot"
+	graphFormatMermaid = "mermaid"
+)
+
+type graphNode struct {
+	Path string `json:"path"`
+	Kind string `json:"kind"`
+}
+
+type graphEdge struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// packageGraph is an import graph. Edges are kept per importing package,
+// sorted, so that output is stable.
+type packageGraph struct {
+	kinds   map[string]string
+	imports map[string][]string
+}
+
+type packageGraphResult struct {
+	Nodes []graphNode `json:"nodes,omitempty"`
+	Edges []graphEdge `json:"edges,omitempty"`
+	// Graph is the DOT or Mermaid rendering of the graph
+	Graph  string     `json:"graph,omitempty"`
+	Cycles [][]string `json:"cycles"`
+	// DirectImporters lists the packages directly importing the package
+	// whose reverse dependencies were requested
+	DirectImporters []string `json:"direct_importers,omitempty"`
+}
+
+// newPackageGraph builds the import graph of "go list -deps" output, keeping
+// only packages of the included kinds
+func newPackageGraph(packages []gocmd.Package, include map[string]bool) *packageGraph {
+	g := &packageGraph{
+		kinds:   make(map[string]string),
+		imports: make(map[string][]string),
+	}
+
+	for _, pkg := range packages {
+		kind := packageKindThirdParty
+		switch {
+		case pkg.Standard:
+			kind = packageKindStdlib
+		case pkg.Module != nil && pkg.Module.Main:
+			kind = packageKindWorkspace
+		}
+		if include[kind] {
+			g.kinds[pkg.ImportPath] = kind
+		}
+	}
+
+	for _, pkg := range packages {
+		if _, ok := g.kinds[pkg.ImportPath]; !ok {
+			continue
+		}
+		for _, imported := range pkg.Imports {
+			if _, ok := g.kinds[imported]; ok {
+				g.imports[pkg.ImportPath] = append(g.imports[pkg.ImportPath], imported)
+			}
+		}
+		sort.Strings(g.imports[pkg.ImportPath])
+	}
+
+	return g
+}
+
+// reachable returns the packages reachable from root following the edges
+// returned by next, including root itself
+func reachable(root string, next func(string) []string) map[string]bool {
+	seen := map[string]bool{root: true}
+	queue := []string{root}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, n := range next(current) {
+			if !seen[n] {
+				seen[n] = true
+				queue = append(queue, n)
+			}
+		}
+	}
+	return seen
+}
+
+// importers returns the reverse edges of the graph
+func (g *packageGraph) importers() map[string][]string {
+	reverse := make(map[string][]string)
+	for from, imports := range g.imports {
+		for _, to := range imports {
+			reverse[to] = append(reverse[to], from)
+		}
+	}
+	for _, from := range reverse {
+		sort.Strings(from)
+	}
+	return reverse
+}
+
+// restrict removes the packages not in keep
+func (g *packageGraph) restrict(keep map[string]bool) {
+	for path := range g.kinds {
+		if !keep[path] {
+			delete(g.kinds, path)
+			delete(g.imports, path)
+		}
+	}
+	for from, imports := range g.imports {
+		kept := imports[:0]
+		for _, to := range imports {
+			if keep[to] {
+				kept = append(kept, to)
+			}
+		}
+		g.imports[from] = kept
+	}
+}
+
+func (g *packageGraph) paths() []string {
+	paths := make([]string, 0, len(g.kinds))
+	for path := range g.kinds {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// cycles returns the import cycles of the graph, as the strongly connected
+// components of more than one package, found with Tarjan's algorithm
+func (g *packageGraph) cycles() [][]string {
+	index := make(map[string]int)
+	lowlink := make(map[string]int)
+	onStack := make(map[string]bool)
+	var stack []string
+	cycles := [][]string{}
+
+	var connect func(v string)
+	connect = func(v string) {
+		index[v] = len(index)
+		lowlink[v] = index[v]
+		stack = append(stack, v)
+		onStack[v] = true
+
+		for _, w := range g.imports[v] {
+			if _, visited := index[w]; !visited {
+				connect(w)
+				lowlink[v] = min(lowlink[v], lowlink[w])
+			} else if onStack[w] {
+				lowlink[v] = min(lowlink[v], index[w])
+			}
+		}
+
+		if lowlink[v] == index[v] {
+			var component []string
+			for {
+				w := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+				onStack[w] = false
+				component = append(component, w)
+				if w == v {
+					break
+				}
+			}
+			if len(component) > 1 {
+				sort.Strings(component)
+				cycles = append(cycles, component)
+			}
+		}
+	}
+
+	for _, path := range g.paths() {
+		if _, visited := index[path]; !visited {
+			connect(path)
+		}
+	}
+	return cycles
+}
+
+func (g *packageGraph) renderDOT() string {
+	var b strings.Builder
+	b.WriteString("digraph packages {\n\trankdir=LR;\n\tnode [shape=box];\n")
+	for _, path := range g.paths() {
+		style := ""
+		switch g.kinds[path] {
+		case packageKindStdlib:
+			style = " [style=dashed]"
+		case packageKindThirdParty:
+			style = " [style=dotted]"
+		}
+		fmt.Fprintf(&b, "\t%q%s;\n", path, style)
+	}
+	for _, from := range g.paths() {
+		for _, to := range g.imports[from] {
+			fmt.Fprintf(&b, "\t%q -> %q;\n", from, to)
+		}
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+func (g *packageGraph) renderMermaid() string {
+	ids := make(map[string]string)
+	var b strings.Builder
+	b.WriteString("graph LR\n")
+	for i, path := range g.paths() {
+		ids[path] = fmt.Sprintf("p%d", i)
+		fmt.Fprintf(&b, "    %s[\"%s\"]\n", ids[path], path)
+	}
+	for _, from := range g.paths() {
+		for _, to := range g.imports[from] {
+			fmt.Fprintf(&b, "    %s --> %s\n", ids[from], ids[to])
+		}
+	}
+	return b.String()
+}
+
+func (t *LSPTools) registerPackageGraph(s *server.MCPServer) {
+	packageGraphTool := mcp.NewTool("package_graph",
+		mcp.WithDescription("PACKAGE LAYERING AND DEPENDENCIES: Use this tool to see which packages import which before moving code or adding an import. Returns the import graph among workspace packages, optionally rooted at one package or reversed to answer 'who imports this package?', along with any import cycles. Standard library and third-party packages can be included. Renders as JSON, Graphviz DOT or Mermaid."),
+		mcp.WithString("dir",
+			mcp.Description("Module directory, as a path or file:// URI (default: the server's working directory)"),
+		),
+		mcp.WithString("root",
+			mcp.Description("Only show the packages imported, directly or not, by this import path"),
+		),
+		mcp.WithString("importers_of",
+			mcp.Description("Only show the packages importing, directly or not, this import path (reverse dependencies)"),
+		),
+		mcp.WithBoolean("include_stdlib",
+			mcp.Description("Include standard library packages (default false)"),
+		),
+		mcp.WithBoolean("include_third_party",
+			mcp.Description("Include packages of other modules (default false)"),
+		),
+		mcp.WithString("format",
+			mcp.Description("Output format (default 'json')"),
+			mcp.Enum(graphFormatJSON, graphFormatDOT, graphFormatMermaid),
+		),
+	)
+
+	s.AddTool(packageGraphTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		dir := request.GetString("dir", "")
+		if dir != "" {
+			dir = resolveDir(dir)
+		} else {
+			wd, err := os.Getwd()
+			if err != nil {
+				return nil, fmt.Errorf("failed to get working directory: %w", err)
+			}
+			dir = wd
+		}
+
+		root := request.GetString("root", "")
+		importersOf := request.GetString("importers_of", "")
+		if root != "" && importersOf != "" {
+			return nil, errors.New("root and importers_of cannot be combined")
+		}
+
+		format := request.GetString("format", graphFormatJSON)
+		if format != graphFormatJSON && format != graphFormatDOT && format != graphFormatMermaid {
+			return nil, fmt.Errorf("unknown format: %s", format)
+		}
+
+		include := map[string]bool{
+			packageKindWorkspace:  true,
+			packageKindStdlib:     request.GetBool("include_stdlib", false),
+			packageKindThirdParty: request.GetBool("include_third_party", false),
+		}
+
+		patterns := []string{"./..."}
+		if root != "" {
+			patterns = append(patterns, root)
+		}
+		packages, err := t.goRunner().ListPackages(ctx, dir, true, patterns...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list packages: %w", err)
+		}
+
+		g := newPackageGraph(packages, include)
+		result := packageGraphResult{}
+
+		switch {
+		case root != "":
+			if _, ok := g.kinds[root]; !ok {
+				return nil, fmt.Errorf("package %s is not in the graph", root)
+			}
+			g.restrict(reachable(root, func(path string) []string { return g.imports[path] }))
+		case importersOf != "":
+			if _, ok := g.kinds[importersOf]; !ok {
+				return nil, fmt.Errorf("package %s is not in the graph", importersOf)
+			}
+			reverse := g.importers()
+			result.DirectImporters = reverse[importersOf]
+			if result.DirectImporters == nil {
+				result.DirectImporters = []string{}
+			}
+			g.restrict(reachable(importersOf, func(path string) []string { return reverse[path] }))
+		}
+
+		result.Cycles = g.cycles()
+
+		switch format {
+		case graphFormatDOT:
+			result.Graph = g.renderDOT()
+		case graphFormatMermaid:
+			result.Graph = g.renderMermaid()
+		default:
+			result.Nodes = []graphNode{}
+			result.Edges = []graphEdge{}
+			for _, path := range g.paths() {
+				result.Nodes = append(result.Nodes, graphNode{Path: path, Kind: g.kinds[path]})
+				for _, to := range g.imports[path] {
+					result.Edges = append(result.Edges, graphEdge{From: path, To: to})
+				}
+			}
+		}
+
+		data, err := json.Marshal(result)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal result: %w", err)
+		}
+
+		return mcp.NewToolResultText(string(data)), nil
+	})
+}
@@ -0,0 +1,127 @@
+package tools
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/solatis/mcp-gopls/pkg/gocmd"
+)
+
+func graphOf(imports map[string][]string) *packageGraph {
+	var packages []gocmd.Package
+	for path, imported := range imports {
+		packages = append(packages, gocmd.Package{ImportPath: path, Imports: imported, Module: &gocmd.Module{Path: "example.com/app", Main: true}})
+	}
+	return newPackageGraph(packages, map[string]bool{packageKindWorkspace: true})
+}
+
+func TestPackageGraphCycles(t *testing.T) {
+	tests := []struct {
+		name    string
+		imports map[string][]string
+		want    [][]string
+	}{
+		{
+			name: "acyclic",
+			imports: map[string][]string{
+				"a": {"b", "c"},
+				"b": {"c"},
+				"c": nil,
+			},
+			want: [][]string{},
+		},
+		{
+			name: "two-package cycle",
+			imports: map[string][]string{
+				"a": {"b"},
+				"b": {"a"},
+			},
+			want: [][]string{{"a", "b"}},
+		},
+		{
+			name: "separate cycles and a tail",
+			imports: map[string][]string{
+				"main": {"a", "x"},
+				"a":    {"b"},
+				"b":    {"c"},
+				"c":    {"a", "util"},
+				"x":    {"y"},
+				"y":    {"x", "util"},
+				"util": nil,
+			},
+			want: [][]string{{"a", "b", "c"}, {"x", "y"}},
+		},
+		{
+			name: "nested cycles form one component",
+			imports: map[string][]string{
+				"a": {"b"},
+				"b": {"a", "c"},
+				"c": {"b"},
+			},
+			want: [][]string{{"a", "b", "c"}},
+		},
+		{
+			name: "imports outside the graph are ignored",
+			imports: map[string][]string{
+				"a": {"fmt"},
+			},
+			want: [][]string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := graphOf(tt.imports).cycles(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("cycles = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPackageGraphReverse(t *testing.T) {
+	g := graphOf(map[string][]string{
+		"cmd":    {"server", "util"},
+		"server": {"util"},
+		"util":   nil,
+		"other":  nil,
+	})
+
+	importers := g.importers()
+	if want := []string{"cmd", "server"}; !reflect.DeepEqual(importers["util"], want) {
+		t.Errorf("importers of util = %v, want %v", importers["util"], want)
+	}
+
+	dependents := reachable("util", func(path string) []string { return importers[path] })
+	g.restrict(dependents)
+	if want := []string{"cmd", "server", "util"}; !reflect.DeepEqual(g.paths(), want) {
+		t.Errorf("restricted paths = %v, want %v", g.paths(), want)
+	}
+	if want := []string{"server", "util"}; !reflect.DeepEqual(g.imports["cmd"], want) {
+		t.Errorf("imports of cmd = %v, want %v", g.imports["cmd"], want)
+	}
+}
+
+func TestPackageGraphKinds(t *testing.T) {
+	main := &gocmd.Module{Path: "example.com/app", Main: true}
+	dependency := &gocmd.Module{Path: "golang.org/x/net", Version: "v0.30.0"}
+
+	// A root outside the main module is listed without DepOnly, like the
+	// packages of the workspace
+	packages := []gocmd.Package{
+		{ImportPath: "golang.org/x/net/http2", Module: dependency, Imports: []string{"golang.org/x/net/http2/hpack", "net/http"}},
+		{ImportPath: "golang.org/x/net/http2/hpack", Module: dependency, DepOnly: true},
+		{ImportPath: "net/http", Standard: true, DepOnly: true},
+		{ImportPath: "example.com/app/server", Module: main, DepOnly: true},
+	}
+
+	g := newPackageGraph(packages, map[string]bool{packageKindWorkspace: true, packageKindStdlib: true, packageKindThirdParty: true})
+	want := map[string]string{
+		"golang.org/x/net/http2":       packageKindThirdParty,
+		"golang.org/x/net/http2/hpack": packageKindThirdParty,
+		"net/http":                     packageKindStdlib,
+		"example.com/app/server":       packageKindWorkspace,
+	}
+	if !reflect.DeepEqual(g.kinds, want) {
+		t.Errorf("kinds = %v, want %v", g.kinds, want)
+	}
+}
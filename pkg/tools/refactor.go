@@ -0,0 +1,232 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/solatis/mcp-gopls/pkg/diff"
+	"github.com/solatis/mcp-gopls/pkg/lsp/client"
+	"github.com/solatis/mcp-gopls/pkg/lsp/protocol"
+)
+
+// refactorResult reports the effect of a code action on a file
+type refactorResult struct {
+	Action      string                `json:"action"`
+	Changed     bool                  `json:"changed"`
+	Diff        string                `json:"diff,omitempty"`
+	Diagnostics []protocol.Diagnostic `json:"diagnostics"`
+}
+
+// parsePosition reads a {"line", "character"} object of tool arguments
+func parsePosition(value any) (protocol.Position, error) {
+	obj, ok := value.(map[string]any)
+	if !ok {
+		return protocol.Position{}, errors.New("position must be an object")
+	}
+	line, ok := obj["line"].(float64)
+	if !ok {
+		return protocol.Position{}, errors.New("line must be a number")
+	}
+	character, ok := obj["character"].(float64)
+	if !ok {
+		return protocol.Position{}, errors.New("character must be a number")
+	}
+	return protocol.Position{Line: int(line), Character: int(character)}, nil
+}
+
+// lineRange returns the range from the start of startLine to the end of
+// endLine, both 0-indexed, without the final line break
+func lineRange(content string, startLine, endLine int) (protocol.Range, error) {
+	lines := strings.Split(content, "\n")
+	if startLine < 0 || endLine < startLine || endLine >= len(lines) {
+		return protocol.Range{}, fmt.Errorf("invalid line range %d-%d for a file of %d lines", startLine, endLine, len(lines))
+	}
+
+	end := 0
+	for _, line := range lines[:endLine] {
+		end += len(line) + 1
+	}
+	end += len(strings.TrimSuffix(lines[endLine], "\r"))
+
+	return protocol.Range{
+		Start: protocol.Position{Line: startLine},
+		End:   protocol.OffsetToPosition(content, end),
+	}, nil
+}
+
+// selectCodeAction picks the enabled action of exactly the given kind,
+// explaining what was offered instead when there is none
+func selectCodeAction(actions []protocol.CodeAction, kind string) (protocol.CodeAction, error) {
+	var offered []string
+	for _, action := range actions {
+		if action.Kind == kind && action.Disabled == nil {
+			return action, nil
+		}
+
+		description := fmt.Sprintf("%q (%s)", action.Title, action.Kind)
+		if action.Disabled != nil {
+			description += ": " + action.Disabled.Reason
+		}
+		offered = append(offered, description)
+	}
+
+	if len(offered) == 0 {
+		return protocol.CodeAction{}, fmt.Errorf("gopls offers no %s action here", kind)
+	}
+	return protocol.CodeAction{}, fmt.Errorf("gopls offers no %s action here, only: %s", kind, strings.Join(offered, "; "))
+}
+
+// applyCodeAction applies a code action expected to change the file at uri,
+// returning the diff of the file and its diagnostics after the change
+func applyCodeAction(lspClient client.LSPClient, uri string, action protocol.CodeAction) (refactorResult, error) {
+	path := protocol.URIToPath(uri)
+	before, err := os.ReadFile(path)
+	if err != nil {
+		return refactorResult{}, fmt.Errorf("failed to read file: %w", err)
+	}
+
+	if err := lspClient.ApplyCodeAction(action); err != nil {
+		return refactorResult{}, err
+	}
+
+	after, err := os.ReadFile(path)
+	if err != nil {
+		return refactorResult{}, fmt.Errorf("failed to read file: %w", err)
+	}
+
+	diagnostics, err := lspClient.GetDiagnostics(uri)
+	if err != nil {
+		return refactorResult{}, err
+	}
+	if diagnostics == nil {
+		diagnostics = []protocol.Diagnostic{}
+	}
+
+	name := filepath.Base(path)
+	return refactorResult{
+		Action:      action.Title,
+		Changed:     string(before) != string(after),
+		Diff:        diff.Unified("a/"+name, "b/"+name, string(before), string(after)),
+		Diagnostics: diagnostics,
+	}, nil
+}
+
+func (t *LSPTools) registerExtract(s *server.MCPServer) {
+	extractTool := mcp.NewTool("extract",
+		mcp.WithDescription("EXTRACT FUNCTION/METHOD/VARIABLE/CONSTANT REFACTORING: Use this tool instead of moving code by hand to extract a selection into a new function, method, variable or constant, exactly as gopls does it: free variables become parameters, results are returned, and every use is rewritten. Select the code with 'range' or with 'start_line'/'end_line'. The edit is applied to the file; returns the diff and the diagnostics of the file afterwards. Rename the generated name (e.g. 'newFunction' or 'x') afterwards if needed."),
+		mcp.WithString("file_uri",
+			mcp.Required(),
+			mcp.Description("URI or absolute path of the Go file"),
+		),
+		mcp.WithString("kind",
+			mcp.Required(),
+			mcp.Description("What to extract the selection into"),
+			mcp.Enum("function", "method", "variable", "constant"),
+		),
+		mcp.WithObject("range",
+			mcp.Description("Selection to extract: an object with 'start' and 'end' positions, each with 'line' (0-indexed) and 'character' (0-indexed) keys. An expression for variable and constant, statements for function and method"),
+		),
+		mcp.WithNumber("start_line",
+			mcp.Description("First line (0-indexed) of the statements to extract, when no range is given"),
+		),
+		mcp.WithNumber("end_line",
+			mcp.Description("Last line (0-indexed, inclusive) of the statements to extract, when no range is given"),
+		),
+		mcp.WithBoolean("all_occurrences",
+			mcp.Description("For variable and constant: replace every occurrence of the expression in the function, not just the selected one"),
+		),
+	)
+
+	s.AddTool(extractTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		fileURI := request.GetString("file_uri", "")
+		if fileURI == "" {
+			return nil, errors.New("file_uri is required")
+		}
+		if !strings.HasPrefix(fileURI, "file://") {
+			fileURI = convertPathToURI(fileURI)
+		}
+
+		kind := request.GetString("kind", "")
+		switch kind {
+		case "function", "method", "variable", "constant":
+		default:
+			return nil, fmt.Errorf("invalid kind: %q", kind)
+		}
+		actionKind := "refactor.extract." + kind
+		if request.GetBool("all_occurrences", false) {
+			if kind != "variable" && kind != "constant" {
+				return nil, errors.New("all_occurrences only applies to variable and constant")
+			}
+			actionKind += "-all"
+		}
+
+		var rng protocol.Range
+		args := request.GetArguments()
+		if rangeObj, ok := args["range"].(map[string]any); ok {
+			start, err := parsePosition(rangeObj["start"])
+			if err != nil {
+				return nil, fmt.Errorf("invalid range start: %w", err)
+			}
+			end, err := parsePosition(rangeObj["end"])
+			if err != nil {
+				return nil, fmt.Errorf("invalid range end: %w", err)
+			}
+			rng = protocol.Range{Start: start, End: end}
+		} else {
+			startLine, ok := args["start_line"].(float64)
+			if !ok {
+				return nil, errors.New("either range or start_line and end_line are required")
+			}
+			endLine := request.GetInt("end_line", int(startLine))
+
+			content, err := os.ReadFile(protocol.URIToPath(fileURI))
+			if err != nil {
+				return nil, fmt.Errorf("failed to read file: %w", err)
+			}
+			rng, err = lineRange(string(content), int(startLine), endLine)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		lspClient := t.getClient()
+		if lspClient == nil {
+			return nil, errors.New("LSP client not available")
+		}
+
+		actions, err := lspClient.CodeActions(fileURI, rng, "refactor.extract")
+		if err != nil {
+			return nil, t.handleLSPError(err)
+		}
+		// gopls before v0.17 reports every extraction as "refactor.extract"
+		for i := range actions {
+			if actions[i].Kind == "refactor.extract" && strings.EqualFold(actions[i].Title, "Extract "+kind) {
+				actions[i].Kind = actionKind
+			}
+		}
+		action, err := selectCodeAction(actions, actionKind)
+		if err != nil {
+			return nil, err
+		}
+
+		result, err := applyCodeAction(lspClient, fileURI, action)
+		if err != nil {
+			return nil, t.handleLSPError(err)
+		}
+
+		data, err := json.Marshal(result)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal result: %w", err)
+		}
+
+		return mcp.NewToolResultText(string(data)), nil
+	})
+}
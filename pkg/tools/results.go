@@ -0,0 +1,205 @@
+package tools
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/solatis/mcp-gopls/pkg/lsp/protocol"
+)
+
+// defaultResultLimit is the page size used when the caller does not provide a
+// limit. It keeps responses for widely-used symbols within a sane size.
+const defaultResultLimit = 100
+
+const (
+	groupByNone    = "none"
+	groupByFile    = "file"
+	groupByPackage = "package"
+)
+
+// resultOptions controls how large result sets are trimmed before they are
+// returned to the client.
+type resultOptions struct {
+	Limit     int
+	Offset    int
+	GroupBy   string
+	CountOnly bool
+}
+
+// resultGroup holds the items sharing the same file or package.
+type resultGroup[T any] struct {
+	Key   string `json:"key"`
+	Count int    `json:"count"`
+	Items []T    `json:"items,omitempty"`
+}
+
+// resultPage is the envelope returned by tools producing potentially large
+// result sets.
+type resultPage[T any] struct {
+	Total      int              `json:"total"`
+	Offset     int              `json:"offset"`
+	Count      int              `json:"count"`
+	Items      []T              `json:"items,omitempty"`
+	Groups     []resultGroup[T] `json:"groups,omitempty"`
+	Truncated  bool             `json:"truncated"`
+	NextOffset int              `json:"next_offset,omitempty"`
+	Notice     string           `json:"notice,omitempty"`
+}
+
+// withResultOptions appends the pagination and grouping parameters shared by
+// all list-returning tools to the given tool options.
+func withResultOptions(opts ...mcp.ToolOption) []mcp.ToolOption {
+	return append(opts,
+		mcp.WithNumber("limit",
+			mcp.Description(fmt.Sprintf("Maximum number of results to return (default %d, 0 for no limit)", defaultResultLimit)),
+			mcp.Min(0),
+		),
+		mcp.WithNumber("offset",
+			mcp.Description("Number of results to skip, use the 'next_offset' of a previous truncated response to get the next page"),
+			mcp.Min(0),
+		),
+		mcp.WithString("group_by",
+			mcp.Description("Group results by 'file' or 'package' (directory). Defaults to 'none'"),
+			mcp.Enum(groupByNone, groupByFile, groupByPackage),
+		),
+		mcp.WithBoolean("count_only",
+			mcp.Description("Only return the total count (and per-group counts when group_by is set), without the results themselves"),
+		),
+	)
+}
+
+func parseResultOptions(request mcp.CallToolRequest) (resultOptions, error) {
+	opts := resultOptions{
+		Limit:     request.GetInt("limit", defaultResultLimit),
+		Offset:    request.GetInt("offset", 0),
+		GroupBy:   request.GetString("group_by", groupByNone),
+		CountOnly: request.GetBool("count_only", false),
+	}
+
+	if opts.Limit < 0 {
+		return opts, errors.New("limit must not be negative")
+	}
+	if opts.Offset < 0 {
+		return opts, errors.New("offset must not be negative")
+	}
+
+	switch opts.GroupBy {
+	case groupByNone, groupByFile, groupByPackage:
+	default:
+		return opts, fmt.Errorf("unsupported group_by value: %s", opts.GroupBy)
+	}
+
+	return opts, nil
+}
+
+// paginate applies the result options to items. uriOf returns the document
+// URI of an item and is used for grouping.
+func paginate[T any](items []T, opts resultOptions, uriOf func(T) string) resultPage[T] {
+	page := resultPage[T]{
+		Total:  len(items),
+		Offset: opts.Offset,
+	}
+
+	if opts.CountOnly {
+		if opts.GroupBy != groupByNone {
+			page.Groups = groupResults(items, opts.GroupBy, uriOf, false)
+		}
+		return page
+	}
+
+	start := min(opts.Offset, len(items))
+	end := len(items)
+	if opts.Limit > 0 && start+opts.Limit < end {
+		end = start + opts.Limit
+	}
+	selected := items[start:end]
+	page.Count = len(selected)
+
+	if end < len(items) {
+		page.Truncated = true
+		page.NextOffset = end
+		page.Notice = fmt.Sprintf("Showing results %d-%d of %d. Call again with offset=%d for more, or use group_by/count_only for a summary.", start+1, end, len(items), end)
+	}
+
+	if opts.GroupBy == groupByNone {
+		page.Items = selected
+	} else {
+		page.Groups = groupResults(selected, opts.GroupBy, uriOf, true)
+	}
+
+	return page
+}
+
+func groupResults[T any](items []T, groupBy string, uriOf func(T) string, withItems bool) []resultGroup[T] {
+	var groups []resultGroup[T]
+	index := make(map[string]int)
+
+	for _, item := range items {
+		key := uriOf(item)
+		if groupBy == groupByPackage {
+			key = packageDirOf(key)
+		}
+
+		i, ok := index[key]
+		if !ok {
+			i = len(groups)
+			index[key] = i
+			groups = append(groups, resultGroup[T]{Key: key})
+		}
+
+		groups[i].Count++
+		if withItems {
+			groups[i].Items = append(groups[i].Items, item)
+		}
+	}
+
+	return groups
+}
+
+// packageDirOf returns the directory of a file URI, which is the unit Go uses
+// for packages.
+func packageDirOf(uri string) string {
+	return filepath.Dir(protocol.URIToPath(uri))
+}
+
+// sortLocations orders locations by file and position so pages are stable
+// across calls.
+func sortLocations(locations []protocol.Location) {
+	sort.SliceStable(locations, func(i, j int) bool {
+		a, b := locations[i], locations[j]
+		if a.URI != b.URI {
+			return a.URI < b.URI
+		}
+		if a.Range.Start.Line != b.Range.Start.Line {
+			return a.Range.Start.Line < b.Range.Start.Line
+		}
+		return a.Range.Start.Character < b.Range.Start.Character
+	})
+}
+
+// sortSymbols orders symbols by file, line and name, so that each file or
+// package forms a single group.
+func sortSymbols(symbols []protocol.SymbolInformation) {
+	sort.SliceStable(symbols, func(i, j int) bool {
+		a, b := symbols[i], symbols[j]
+		if a.Location.URI != b.Location.URI {
+			return a.Location.URI < b.Location.URI
+		}
+		if a.Location.Range.Start.Line != b.Location.Range.Start.Line {
+			return a.Location.Range.Start.Line < b.Location.Range.Start.Line
+		}
+		return a.Name < b.Name
+	})
+}
+
+func locationURI(l protocol.Location) string {
+	return l.URI
+}
+
+func symbolURI(s protocol.SymbolInformation) string {
+	return s.Location.URI
+}
@@ -0,0 +1,127 @@
+package tools
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/solatis/mcp-gopls/pkg/lsp/protocol"
+)
+
+func location(uri string, line int) protocol.Location {
+	return protocol.Location{
+		URI:   uri,
+		Range: protocol.Range{Start: protocol.Position{Line: line}},
+	}
+}
+
+func TestPaginate(t *testing.T) {
+	items := []protocol.Location{
+		location("file:///a/x.go", 1),
+		location("file:///a/x.go", 2),
+		location("file:///a/y.go", 3),
+		location("file:///b/z.go", 4),
+		location("file:///b/z.go", 5),
+	}
+
+	tests := []struct {
+		name       string
+		opts       resultOptions
+		wantLines  []int
+		wantGroups map[string]int
+		truncated  bool
+		nextOffset int
+	}{
+		{
+			name:      "no limit",
+			opts:      resultOptions{GroupBy: groupByNone},
+			wantLines: []int{1, 2, 3, 4, 5},
+		},
+		{
+			name:       "first page",
+			opts:       resultOptions{Limit: 2, GroupBy: groupByNone},
+			wantLines:  []int{1, 2},
+			truncated:  true,
+			nextOffset: 2,
+		},
+		{
+			name:      "last page",
+			opts:      resultOptions{Limit: 2, Offset: 4, GroupBy: groupByNone},
+			wantLines: []int{5},
+		},
+		{
+			name:      "offset past the end",
+			opts:      resultOptions{Limit: 2, Offset: 10, GroupBy: groupByNone},
+			wantLines: nil,
+		},
+		{
+			name:       "group by file",
+			opts:       resultOptions{Limit: 4, GroupBy: groupByFile},
+			wantGroups: map[string]int{"file:///a/x.go": 2, "file:///a/y.go": 1, "file:///b/z.go": 1},
+			truncated:  true,
+			nextOffset: 4,
+		},
+		{
+			name:       "count by package",
+			opts:       resultOptions{Limit: 1, GroupBy: groupByPackage, CountOnly: true},
+			wantGroups: map[string]int{"/a": 3, "/b": 2},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page := paginate(items, tt.opts, locationURI)
+
+			if page.Total != len(items) {
+				t.Errorf("Total = %d, want %d", page.Total, len(items))
+			}
+			if page.Truncated != tt.truncated || page.NextOffset != tt.nextOffset {
+				t.Errorf("Truncated, NextOffset = %v, %d, want %v, %d", page.Truncated, page.NextOffset, tt.truncated, tt.nextOffset)
+			}
+
+			var lines []int
+			for _, item := range page.Items {
+				lines = append(lines, item.Range.Start.Line)
+			}
+			if !reflect.DeepEqual(lines, tt.wantLines) {
+				t.Errorf("lines = %v, want %v", lines, tt.wantLines)
+			}
+
+			var groups map[string]int
+			for _, group := range page.Groups {
+				if groups == nil {
+					groups = make(map[string]int)
+				}
+				groups[group.Key] = group.Count
+				if tt.opts.CountOnly && len(group.Items) > 0 {
+					t.Errorf("group %s has items in count_only mode", group.Key)
+				}
+			}
+			if !reflect.DeepEqual(groups, tt.wantGroups) {
+				t.Errorf("groups = %v, want %v", groups, tt.wantGroups)
+			}
+		})
+	}
+}
+
+func TestSortSymbols(t *testing.T) {
+	symbol := func(name, uri string, line int) protocol.SymbolInformation {
+		return protocol.SymbolInformation{Name: name, Location: location(uri, line)}
+	}
+
+	symbols := []protocol.SymbolInformation{
+		symbol("Zeta", "file:///b.go", 1),
+		symbol("Beta", "file:///a.go", 7),
+		symbol("Alpha", "file:///a.go", 7),
+		symbol("Gamma", "file:///a.go", 2),
+	}
+	sortSymbols(symbols)
+
+	var names []string
+	for _, s := range symbols {
+		names = append(names, s.Name)
+	}
+	want := []string{"Gamma", "Alpha", "Beta", "Zeta"}
+	if !reflect.DeepEqual(names, want) {
+		t.Errorf("sorted names = %v, want %v", names, want)
+	}
+}
@@ -0,0 +1,535 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/solatis/mcp-gopls/pkg/lsp/client"
+	"github.com/solatis/mcp-gopls/pkg/lsp/protocol"
+)
+
+const (
+	methodOK              = "ok"
+	methodPointerReceiver = "pointer_receiver"
+	methodMissing         = "missing"
+	methodMismatched      = "mismatched"
+	methodPromoted        = "promoted"
+
+	// satisfactionCheckFile is the name of the file of assertions sent to
+	// gopls as an overlay; it is never written to disk
+	satisfactionCheckFile = "zz_mcp_gopls_satisfaction"
+)
+
+var (
+	// methodProblem matches the reason of a failed interface conversion
+	// reported by the type checker
+	methodProblem = regexp.MustCompile(`\((missing method|wrong type for method|method) (\w+)( has pointer receiver)?\)`)
+	// packageQualifier matches the package qualifiers of a type expression
+	packageQualifier = regexp.MustCompile(`\b[A-Za-z_][A-Za-z0-9_]*\.`)
+)
+
+type methodCheck struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	Want   string `json:"want,omitempty"`
+	Have   string `json:"have,omitempty"`
+}
+
+type interfaceCheck struct {
+	Interface        string        `json:"interface"`
+	ValueSatisfies   bool          `json:"value_satisfies"`
+	PointerSatisfies bool          `json:"pointer_satisfies"`
+	ValueError       string        `json:"value_error,omitempty"`
+	PointerError     string        `json:"pointer_error,omitempty"`
+	Methods          []methodCheck `json:"methods"`
+}
+
+// satisfiedInterface is an interface implemented by the type or its pointer.
+// Value and Pointer are unset when the interface cannot be referenced from the
+// package of the type, such as an unexported interface of another package.
+type satisfiedInterface struct {
+	Name    string `json:"name"`
+	Package string `json:"package"`
+	URI     string `json:"uri"`
+	Line    int    `json:"line"`
+	Value   *bool  `json:"value,omitempty"`
+	Pointer *bool  `json:"pointer,omitempty"`
+}
+
+// listedInterface is a satisfied interface with the lines of its assertions
+// in the check file, -1 when it is not checked
+type listedInterface struct {
+	satisfiedInterface
+	lines [2]int
+}
+
+type satisfactionResult struct {
+	Type      typeRef              `json:"type"`
+	Check     *interfaceCheck      `json:"check,omitempty"`
+	Satisfied []satisfiedInterface `json:"satisfied_interfaces,omitempty"`
+	Notes     []string             `json:"notes,omitempty"`
+}
+
+// checkFile builds the content of a file of the type's package converting the
+// type and its pointer to interfaces, importing their packages under aliases
+type checkFile struct {
+	pkgName  string
+	typeName string
+	aliases  map[string]string
+	imports  []string
+	lines    []string
+}
+
+// add appends the assertions for an interface of the package at importPath
+// ("" for the package of the type) and returns the expression naming it and
+// the lines of the value and pointer assertions, counted from the
+// declarations
+func (f *checkFile) add(importPath, name string) (string, int, int) {
+	expr := name
+	if importPath != "" {
+		alias, ok := f.aliases[importPath]
+		if !ok {
+			alias = fmt.Sprintf("iface%d", len(f.aliases))
+			f.aliases[importPath] = alias
+			f.imports = append(f.imports, fmt.Sprintf("\t%s %q", alias, importPath))
+		}
+		expr = alias + "." + name
+	}
+	f.lines = append(f.lines,
+		fmt.Sprintf("var _ %s = *new(%s)", expr, f.typeName),
+		fmt.Sprintf("var _ %s = (*%s)(nil)", expr, f.typeName),
+	)
+	return expr, len(f.lines) - 2, len(f.lines) - 1
+}
+
+// content returns the file and the line the declarations start at
+func (f *checkFile) content() (string, int) {
+	var b strings.Builder
+	fmt.Fprintf(&b, "package %s\n\n", f.pkgName)
+	if len(f.imports) > 0 {
+		b.WriteString("import (\n" + strings.Join(f.imports, "\n") + "\n)\n\n")
+	}
+	first := strings.Count(b.String(), "\n")
+	b.WriteString(strings.Join(f.lines, "\n") + "\n")
+	return b.String(), first
+}
+
+// signatureKey normalizes a function signature such as
+// "func(w http.ResponseWriter, r *Request) error" to the types of its
+// parameters and results, without names or package qualifiers, so that the
+// signatures of an interface method and its implementation compare equal
+func signatureKey(signature string) string {
+	expr, err := parser.ParseExpr(signature)
+	funcType, ok := expr.(*ast.FuncType)
+	if err != nil || !ok {
+		return strings.Join(strings.Fields(packageQualifier.ReplaceAllString(signature, "")), " ")
+	}
+
+	render := func(fields *ast.FieldList) string {
+		if fields == nil {
+			return ""
+		}
+		var types []string
+		for _, field := range fields.List {
+			var buf bytes.Buffer
+			printer.Fprint(&buf, token.NewFileSet(), field.Type)
+			typ := packageQualifier.ReplaceAllString(buf.String(), "")
+			for range max(len(field.Names), 1) {
+				types = append(types, typ)
+			}
+		}
+		return strings.Join(types, ",")
+	}
+	return "(" + render(funcType.Params) + ")(" + render(funcType.Results) + ")"
+}
+
+// parseHaveWant returns the signatures from the "have" and "want" lines of a
+// wrong type for method error
+func parseHaveWant(message string) (have, want string) {
+	for _, line := range strings.Split(message, "\n") {
+		line = strings.TrimSpace(line)
+		if s, ok := strings.CutPrefix(line, "have "); ok {
+			have = s
+		} else if s, ok := strings.CutPrefix(line, "want "); ok {
+			want = s
+		}
+	}
+	return have, want
+}
+
+func (t *LSPTools) registerInterfaceSatisfaction(s *server.MCPServer) {
+	satisfactionTool := mcp.NewTool("interface_satisfaction",
+		mcp.WithDescription("WHY DOESN'T MY TYPE IMPLEMENT THIS INTERFACE? Use this tool when a type should satisfy an interface but the compiler disagrees, or to learn which interfaces a type can be used as. Given a type and an interface, tells whether the type and its pointer satisfy it, and lists each interface method as ok, missing, mismatched (with the expected and actual signatures) or only implemented with a pointer receiver. Also lists every interface of the workspace and its dependencies, standard library included, that the type or its pointer already satisfies. Nothing is written to disk."),
+		mcp.WithString("type_name",
+			mcp.Description("Name of the concrete type, optionally qualified by its package name or path, e.g. 'Server' or 'server.Server'"),
+		),
+		mcp.WithString("type_file_uri",
+			mcp.Description("URI or absolute path of the file declaring the type, with type_position"),
+		),
+		mcp.WithObject("type_position",
+			mcp.Description("Position within the type declaration. Must contain 'line' (0-indexed line number) and 'character' (0-indexed column number) keys"),
+		),
+		mcp.WithString("interface",
+			mcp.Description("Interface to check, as written in the file of the type, e.g. 'io.Reader', 'http.Handler' or 'Store' for one of the same package. Without an interface, only the satisfied interfaces are listed"),
+		),
+		mcp.WithString("interface_import_path",
+			mcp.Description("Import path of the interface's package, when the file of the type does not import it, e.g. 'net/http'"),
+		),
+		mcp.WithString("interface_file_uri",
+			mcp.Description("URI or absolute path of the file declaring the interface, with interface_position, instead of 'interface'"),
+		),
+		mcp.WithObject("interface_position",
+			mcp.Description("Position within the interface declaration. Must contain 'line' (0-indexed line number) and 'character' (0-indexed column number) keys"),
+		),
+		mcp.WithBoolean("list_satisfied",
+			mcp.Description("List the interfaces the type already satisfies (default true)"),
+		),
+	)
+
+	s.AddTool(satisfactionTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		lspClient := t.getClient()
+		if lspClient == nil {
+			return nil, errors.New("LSP client not available")
+		}
+
+		args := request.GetArguments()
+		typ, err := resolveType(lspClient, request.GetString("type_name", ""), request.GetString("type_file_uri", ""), args["type_position"])
+		if err != nil {
+			return nil, t.handleLSPError(err)
+		}
+		if typ.Kind == protocol.SKInterface.String() {
+			return nil, fmt.Errorf("%s is an interface, not a concrete type", typ.Name)
+		}
+
+		typeFile := protocol.URIToPath(typ.URI)
+		typeDir := filepath.Dir(typeFile)
+		content, err := os.ReadFile(typeFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read file: %w", err)
+		}
+		if isGenericType(string(content), typ.Name) {
+			return nil, fmt.Errorf("%s is a generic type, check an instantiation of it by hand", typ.Name)
+		}
+		parsed, err := parser.ParseFile(token.NewFileSet(), typeFile, content, parser.PackageClauseOnly)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", typeFile, err)
+		}
+
+		checkName := satisfactionCheckFile + ".go"
+		if strings.HasSuffix(typeFile, "_test.go") {
+			checkName = satisfactionCheckFile + "_test.go"
+		}
+		checkURI := convertPathToURI(filepath.Join(typeDir, checkName))
+
+		file := &checkFile{pkgName: parsed.Name.Name, typeName: typ.Name, aliases: make(map[string]string)}
+		result := satisfactionResult{Type: typ}
+
+		// The interface to check
+		var check *interfaceCheck
+		var checkLines [2]int
+		var checkColumn int
+		ifaceImportPath := ""
+		if iface := request.GetString("interface", ""); iface != "" {
+			name := iface
+			if qualifier, rest, ok := strings.Cut(iface, "."); ok {
+				name = rest
+				ifaceImportPath = request.GetString("interface_import_path", "")
+				if ifaceImportPath == "" {
+					imports, err := fileImports(string(content))
+					if err != nil {
+						return nil, fmt.Errorf("failed to parse %s: %w", typeFile, err)
+					}
+					for importPath, alias := range imports {
+						if alias == qualifier || (alias == "" && path.Base(importPath) == qualifier) {
+							ifaceImportPath = importPath
+						}
+					}
+					if ifaceImportPath == "" {
+						return nil, fmt.Errorf("%s does not import a package named %s, pass interface_import_path", typeFile, qualifier)
+					}
+				}
+			}
+			check = &interfaceCheck{Interface: iface}
+			var expr string
+			expr, checkLines[0], checkLines[1] = file.add(ifaceImportPath, name)
+			checkColumn = len("var _ ") + len(expr) - 1
+		} else if request.GetString("interface_file_uri", "") != "" {
+			ifaceRef, err := resolveType(lspClient, "", request.GetString("interface_file_uri", ""), args["interface_position"])
+			if err != nil {
+				return nil, t.handleLSPError(err)
+			}
+			if ifaceRef.Kind != protocol.SKInterface.String() {
+				return nil, fmt.Errorf("%s is not an interface", ifaceRef.Name)
+			}
+			ifaceDir := packageDirOf(ifaceRef.URI)
+			check = &interfaceCheck{Interface: ifaceRef.Name}
+			if ifaceDir != typeDir {
+				packages, err := t.goRunner().ListPackages(ctx, ifaceDir, false, ".")
+				if err != nil || len(packages) != 1 {
+					return nil, fmt.Errorf("failed to find the package of %s: %v", ifaceRef.Name, err)
+				}
+				ifaceImportPath = packages[0].ImportPath
+				check.Interface = packages[0].Name + "." + ifaceRef.Name
+			}
+			var expr string
+			expr, checkLines[0], checkLines[1] = file.add(ifaceImportPath, ifaceRef.Name)
+			checkColumn = len("var _ ") + len(expr) - 1
+		}
+
+		// The interfaces gopls finds for the type, checked as well when
+		// they can be referenced from its package
+		var satisfied []*listedInterface
+		if request.GetBool("list_satisfied", true) {
+			satisfied, err = t.satisfiedInterfaces(lspClient, typ)
+			if err != nil {
+				return nil, t.handleLSPError(err)
+			}
+		}
+		var importPaths map[string]string
+		var names map[string]string
+		if len(satisfied) > 0 {
+			dirs := make([]string, 0, len(satisfied))
+			for _, s := range satisfied {
+				dirs = append(dirs, s.Package)
+			}
+			importPaths, names = t.importPaths(ctx, typeDir, dirs)
+		}
+		for _, s := range satisfied {
+			if s.Package == typeDir {
+				_, s.lines[0], s.lines[1] = file.add("", s.Name)
+			} else if importPath, ok := importPaths[s.Package]; ok && names[s.Package] != "main" && isExportedSymbol(s.Name) {
+				_, s.lines[0], s.lines[1] = file.add(importPath, s.Name)
+			} else {
+				s.lines = [2]int{-1, -1}
+			}
+			if importPath, ok := importPaths[s.Package]; ok {
+				s.Package = importPath
+			}
+		}
+
+		// Type check the assertions in an overlay
+		checkContent, first := file.content()
+		var diagnostics []protocol.Diagnostic
+		var ifaceDecl []protocol.Location
+		err = withOverlay(lspClient, checkURI, &checkContent, func() (err error) {
+			if diagnostics, err = lspClient.GetDiagnostics(checkURI); err != nil {
+				return err
+			}
+			if check != nil {
+				ifaceDecl, err = lspClient.GoToDefinition(checkURI, first+checkLines[0], checkColumn)
+			}
+			return err
+		})
+		if err != nil {
+			return nil, t.handleLSPError(err)
+		}
+
+		failures := make(map[int]string)
+		for _, d := range diagnostics {
+			if d.Severity == int(protocol.SeverityError) || d.Severity == 0 {
+				line := d.Range.Start.Line - first
+				if failures[line] == "" {
+					failures[line] = d.Message
+				}
+			}
+		}
+		// Messages refer to the interface through its alias in the check file
+		readable := func(message string) string {
+			for importPath, alias := range file.aliases {
+				message = strings.ReplaceAll(message, alias+".", path.Base(importPath)+".")
+			}
+			return message
+		}
+
+		for _, s := range satisfied {
+			if s.lines[0] >= 0 {
+				value, pointer := failures[s.lines[0]] == "", failures[s.lines[1]] == ""
+				s.Value, s.Pointer = &value, &pointer
+			}
+			result.Satisfied = append(result.Satisfied, s.satisfiedInterface)
+		}
+
+		if check != nil {
+			check.ValueError = readable(failures[checkLines[0]])
+			check.PointerError = readable(failures[checkLines[1]])
+			check.ValueSatisfies = check.ValueError == ""
+			check.PointerSatisfies = check.PointerError == ""
+			if !check.PointerSatisfies && !strings.Contains(check.PointerError, "does not implement") {
+				return nil, fmt.Errorf("cannot check %s: %s", check.Interface, check.PointerError)
+			}
+
+			methods, notes, err := compareMethodSets(lspClient, typ, typeDir, ifaceDecl, check)
+			if err != nil {
+				return nil, t.handleLSPError(err)
+			}
+			check.Methods = methods
+			result.Notes = append(result.Notes, notes...)
+			result.Check = check
+		}
+
+		data, err := json.Marshal(result)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal result: %w", err)
+		}
+
+		return mcp.NewToolResultText(string(data)), nil
+	})
+}
+
+// satisfiedInterfaces returns the interfaces gopls reports as implemented by
+// the type or its pointer. Package holds the directory of each interface.
+func (t *LSPTools) satisfiedInterfaces(lspClient client.LSPClient, typ typeRef) ([]*listedInterface, error) {
+	locations, err := lspClient.GetImplementations(typ.URI, typ.Position.Line, typ.Position.Character)
+	if err != nil {
+		return nil, err
+	}
+	sortLocations(locations)
+
+	cache := newSymbolCache(lspClient)
+	var interfaces []*listedInterface
+	for _, location := range locations {
+		symbols, err := cache.get(location.URI)
+		if err != nil {
+			return nil, err
+		}
+		symbol := innermostSymbol(symbols, location.Range.Start)
+		if symbol == nil || symbol.Kind != protocol.SKInterface {
+			continue
+		}
+		interfaces = append(interfaces, &listedInterface{satisfiedInterface: satisfiedInterface{
+			Name:    symbol.Name,
+			Package: packageDirOf(location.URI),
+			URI:     location.URI,
+			Line:    location.Range.Start.Line,
+		}})
+	}
+	return interfaces, nil
+}
+
+// importPaths returns the import paths and package names of package
+// directories, as far as go list knows them
+func (t *LSPTools) importPaths(ctx context.Context, dir string, dirs []string) (map[string]string, map[string]string) {
+	importPaths := make(map[string]string)
+	names := make(map[string]string)
+	packages, err := t.goRunner().ListPackages(ctx, dir, false, dirs...)
+	if err != nil {
+		return importPaths, names
+	}
+	for _, pkg := range packages {
+		if pkg.Error == nil {
+			importPaths[pkg.Dir] = pkg.ImportPath
+			names[pkg.Dir] = pkg.Name
+		}
+	}
+	return importPaths, names
+}
+
+// compareMethodSets reports, for each method of the interface, how the type
+// implements it. The type checker's verdict in check is authoritative; the
+// declared methods of the type explain it.
+func compareMethodSets(lspClient client.LSPClient, typ typeRef, typeDir string, ifaceDecl []protocol.Location, check *interfaceCheck) ([]methodCheck, []string, error) {
+	var notes []string
+	if len(ifaceDecl) == 0 {
+		return []methodCheck{}, []string{"gopls did not locate the interface declaration, methods are not listed"}, nil
+	}
+
+	cache := newSymbolCache(lspClient)
+	symbols, err := cache.get(ifaceDecl[0].URI)
+	if err != nil {
+		return nil, nil, err
+	}
+	iface := innermostSymbol(symbols, ifaceDecl[0].Range.Start)
+	if iface == nil || iface.Kind != protocol.SKInterface {
+		return []methodCheck{}, []string{"gopls did not locate the interface declaration, methods are not listed"}, nil
+	}
+
+	// Methods declared on the type, in the files of its package
+	type declared struct {
+		pointer   bool
+		signature string
+	}
+	methods := make(map[string]declared)
+	entries, err := os.ReadDir(typeDir)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read package directory: %w", err)
+	}
+	testPackage := strings.HasSuffix(typ.URI, "_test.go")
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".go") || (strings.HasSuffix(name, "_test.go") && !testPackage) {
+			continue
+		}
+		fileSymbols, err := cache.get(convertPathToURI(filepath.Join(typeDir, name)))
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, symbol := range fileSymbols {
+			if symbol.Kind != protocol.SKMethod || !isMethodOf(symbol.Name, typ.Name) {
+				continue
+			}
+			method := symbol.Name[strings.Index(symbol.Name, ").")+2:]
+			methods[method] = declared{pointer: strings.HasPrefix(symbol.Name, "(*"), signature: symbol.Detail}
+		}
+	}
+
+	// The type checker names the first problem it finds
+	problem := ""
+	problemMethod := ""
+	if m := methodProblem.FindStringSubmatch(check.PointerError); m != nil {
+		problem, problemMethod = m[1], m[2]
+	} else if m := methodProblem.FindStringSubmatch(check.ValueError); m != nil {
+		problem, problemMethod = m[1], m[2]
+	}
+
+	checks := []methodCheck{}
+	for _, child := range iface.Children {
+		if child.Kind != protocol.SKMethod {
+			notes = append(notes, fmt.Sprintf("%s embeds %s, whose methods are not listed", iface.Name, child.Name))
+			continue
+		}
+
+		mc := methodCheck{Name: child.Name, Want: child.Detail}
+		method, ok := methods[child.Name]
+		switch {
+		case child.Name == problemMethod && problem == "missing method":
+			mc.Status = methodMissing
+		case child.Name == problemMethod && problem == "wrong type for method":
+			mc.Status = methodMismatched
+			mc.Have, mc.Want = parseHaveWant(check.PointerError)
+		case !ok && check.PointerSatisfies:
+			mc.Status = methodPromoted
+		case !ok:
+			mc.Status = methodMissing
+		case !check.PointerSatisfies && signatureKey(method.signature) != signatureKey(child.Detail):
+			mc.Status = methodMismatched
+			mc.Have = method.signature
+		case method.pointer:
+			mc.Status = methodPointerReceiver
+		default:
+			mc.Status = methodOK
+		}
+		checks = append(checks, mc)
+	}
+
+	sort.Slice(checks, func(i, j int) bool { return checks[i].Name < checks[j].Name })
+	if !check.PointerSatisfies {
+		notes = append(notes, "methods promoted from embedded fields are reported as missing")
+	}
+	return checks, notes, nil
+}
@@ -0,0 +1,79 @@
+package tools
+
+import "testing"
+
+func TestSignatureKey(t *testing.T) {
+	tests := []struct {
+		name      string
+		have      string
+		want      string
+		wantEqual bool
+	}{
+		{
+			name:      "parameter names",
+			have:      "func(w http.ResponseWriter, r *http.Request)",
+			want:      "func(http.ResponseWriter, *http.Request)",
+			wantEqual: true,
+		},
+		{
+			name:      "grouped parameters",
+			have:      "func(a, b int) error",
+			want:      "func(x int, y int) (err error)",
+			wantEqual: true,
+		},
+		{
+			name:      "package qualifiers",
+			have:      "func(ctx context.Context) (*store.Item, error)",
+			want:      "func(context.Context) (*Item, error)",
+			wantEqual: true,
+		},
+		{
+			name:      "variadic and composite types",
+			have:      "func(keys ...string) map[string][]byte",
+			want:      "func(...string) (m map[string][]byte)",
+			wantEqual: true,
+		},
+		{
+			name: "different result",
+			have: "func() error",
+			want: "func() (int, error)",
+		},
+		{
+			name: "pointer receiver type",
+			have: "func(r Request)",
+			want: "func(*Request)",
+		},
+		{
+			name: "variadic against slice",
+			have: "func(keys []string)",
+			want: "func(keys ...string)",
+		},
+		{
+			name:      "unparsable signatures fall back to the text",
+			have:      "func(a  pkg.T",
+			want:      "func(a T",
+			wantEqual: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			have, want := signatureKey(tt.have), signatureKey(tt.want)
+			if (have == want) != tt.wantEqual {
+				t.Errorf("signatureKey(%q) = %q, signatureKey(%q) = %q, equal = %v, want %v", tt.have, have, tt.want, want, have == want, tt.wantEqual)
+			}
+		})
+	}
+
+	if got, want := signatureKey("func(w io.Writer, n int) (int, error)"), "(Writer,int)(int,error)"; got != want {
+		t.Errorf("signatureKey = %q, want %q", got, want)
+	}
+}
+
+func TestParseHaveWant(t *testing.T) {
+	message := "*Store does not implement Cache (wrong type for method Get)\n\t\thave Get(key string) (string, bool)\n\t\twant Get(key string) ([]byte, bool)"
+	have, want := parseHaveWant(message)
+	if have != "Get(key string) (string, bool)" || want != "Get(key string) ([]byte, bool)" {
+		t.Errorf("parseHaveWant = %q, %q", have, want)
+	}
+}
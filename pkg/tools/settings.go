@@ -0,0 +1,46 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+func (t *LSPTools) registerSetGoplsSettings(s *server.MCPServer) {
+	settingsTool := mcp.NewTool("set_gopls_settings",
+		mcp.WithDescription("CONFIGURE GOPLS AT RUNTIME: Use this tool to change the settings gopls uses to analyze the code, such as build tags ('buildFlags': ['-tags=integration']), environment overrides ('env': {'GOFLAGS': '-mod=vendor', 'GOOS': 'windows'}), 'directoryFilters', 'analyses', 'staticcheck' or 'hints'. Settings are merged with the current ones, a null value resets a setting to its default. gopls reloads the workspace after the change. Call without settings to get the current settings. Returns the settings in effect."),
+		mcp.WithObject("settings",
+			mcp.Description("gopls settings to change, e.g. {\"buildFlags\": [\"-tags=integration\"], \"staticcheck\": true}"),
+		),
+	)
+
+	s.AddTool(settingsTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		lspClient := t.getClient()
+		if lspClient == nil {
+			return nil, errors.New("LSP client not available")
+		}
+
+		args := request.GetArguments()
+		if raw, ok := args["settings"]; ok && raw != nil {
+			settings, ok := raw.(map[string]any)
+			if !ok {
+				return nil, errors.New("settings must be an object")
+			}
+
+			if err := lspClient.UpdateSettings(settings); err != nil {
+				return nil, t.handleLSPError(err)
+			}
+		}
+
+		result, err := json.Marshal(lspClient.Settings())
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal result: %w", err)
+		}
+
+		return mcp.NewToolResultText(string(result)), nil
+	})
+}
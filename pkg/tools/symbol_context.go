@@ -0,0 +1,347 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/solatis/mcp-gopls/pkg/lsp/client"
+	"github.com/solatis/mcp-gopls/pkg/lsp/protocol"
+)
+
+const (
+	defaultSymbolContextTokens = 2000
+	defaultSymbolContextSites  = 5
+)
+
+// contextSite is a location of a reference or implementation, with the
+// symbol containing it
+type contextSite struct {
+	URI  string `json:"uri"`
+	Line int    `json:"line"`
+	// Symbol is the declaration the site belongs to, such as the calling
+	// function or the implementing type
+	Symbol string `json:"symbol,omitempty"`
+	Text   string `json:"text,omitempty"`
+}
+
+type symbolContextResult struct {
+	Name       string            `json:"name"`
+	Kind       string            `json:"kind,omitempty"`
+	Definition protocol.Location `json:"definition"`
+	// Documentation is the gopls hover text: signature and doc comment
+	Documentation string `json:"documentation,omitempty"`
+	Source        string `json:"source,omitempty"`
+	// SourceLine is the 0-indexed line Source starts at
+	SourceLine      int           `json:"source_line"`
+	ReferenceCount  int           `json:"reference_count"`
+	ReferenceFiles  int           `json:"reference_files"`
+	CallSites       []contextSite `json:"call_sites"`
+	Implementations []contextSite `json:"implementations,omitempty"`
+	// Truncated lists the parts shortened to fit the token budget
+	Truncated       []string `json:"truncated,omitempty"`
+	EstimatedTokens int      `json:"estimated_tokens"`
+}
+
+// estimateTokens approximates the number of tokens of a text, at about four
+// characters per token
+func estimateTokens(text string) int {
+	return (len(text) + 3) / 4
+}
+
+// innermostSymbol returns the most nested symbol whose range contains the
+// position, such as a method of an interface or a field of a struct
+func innermostSymbol(symbols []protocol.DocumentSymbol, pos protocol.Position) *protocol.DocumentSymbol {
+	for i := range symbols {
+		if rangeContains(symbols[i].Range, pos) {
+			if child := innermostSymbol(symbols[i].Children, pos); child != nil {
+				return child
+			}
+			return &symbols[i]
+		}
+	}
+	return nil
+}
+
+// resolveSymbol finds the declaration of a symbol by name, optionally
+// qualified by its package name or path: "Server", "Server.Start",
+// "server.Server.Start" or "github.com/org/repo/server.Server.Start"
+func resolveSymbol(lspClient client.LSPClient, name string) (protocol.SymbolInformation, error) {
+	query := name
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		query = name[i+1:]
+	}
+
+	symbols, err := lspClient.GetWorkspaceSymbols(query)
+	if err != nil {
+		return protocol.SymbolInformation{}, err
+	}
+
+	matchesName := func(symbol protocol.SymbolInformation) bool {
+		if symbol.Name == name {
+			return true
+		}
+		// gopls reports the package path of workspace symbols as container
+		for i := len(name) - 1; i >= 0; i-- {
+			if name[i] != '.' || name[i+1:] != symbol.Name {
+				continue
+			}
+			qualifier := name[:i]
+			if symbol.ContainerName == qualifier || path.Base(symbol.ContainerName) == qualifier {
+				return true
+			}
+		}
+		return false
+	}
+
+	var matches []protocol.SymbolInformation
+	for _, symbol := range symbols {
+		if matchesName(symbol) {
+			matches = append(matches, symbol)
+		}
+	}
+	matches = preferNonTest(matches)
+
+	switch len(matches) {
+	case 0:
+		return protocol.SymbolInformation{}, fmt.Errorf("symbol %s not found in the workspace", name)
+	case 1:
+		return matches[0], nil
+	default:
+		var candidates []string
+		for _, match := range matches {
+			candidates = append(candidates, match.ContainerName+"."+match.Name)
+		}
+		return protocol.SymbolInformation{}, fmt.Errorf("symbol %s is ambiguous, qualify it as one of %s", name, strings.Join(candidates, ", "))
+	}
+}
+
+func (t *LSPTools) registerSymbolContext(s *server.MCPServer) {
+	symbolContextTool := mcp.NewTool("symbol_context",
+		mcp.WithDescription("EVERYTHING ABOUT A SYMBOL IN ONE CALL: Use this tool instead of chaining go_to_definition, hover, document_symbol, find_references and list_interface_implementation to understand a function, method, type or variable. Given a position or a qualified name, returns where it is defined, its signature and doc comment, its declaration source, how often and where it is used with a few sample call sites, and the types implementing it or the interfaces it implements. The response is kept within a token budget, shortening the source and samples first."),
+		mcp.WithString("name",
+			mcp.Description("Name of the symbol, optionally qualified by its type and package, e.g. 'LSPTools', 'LSPTools.Register' or 'tools.LSPTools.Register'. Alternative to file_uri and position"),
+		),
+		mcp.WithString("file_uri",
+			mcp.Description("URI or absolute path of a file using or declaring the symbol, with position"),
+		),
+		mcp.WithObject("position",
+			mcp.Description("Position of the symbol in file_uri. Must contain 'line' (0-indexed line number) and 'character' (0-indexed column number) keys"),
+		),
+		mcp.WithNumber("max_tokens",
+			mcp.Description(fmt.Sprintf("Approximate size budget of the response, in tokens (default %d)", defaultSymbolContextTokens)),
+			mcp.Min(200),
+		),
+		mcp.WithNumber("max_call_sites",
+			mcp.Description(fmt.Sprintf("Maximum number of sample call sites (default %d)", defaultSymbolContextSites)),
+			mcp.Min(0),
+		),
+	)
+
+	s.AddTool(symbolContextTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		name := request.GetString("name", "")
+		fileURI := request.GetString("file_uri", "")
+		positionArg := request.GetArguments()["position"]
+		maxTokens := request.GetInt("max_tokens", defaultSymbolContextTokens)
+		maxSites := request.GetInt("max_call_sites", defaultSymbolContextSites)
+
+		lspClient := t.getClient()
+		if lspClient == nil {
+			return nil, errors.New("LSP client not available")
+		}
+
+		var definition protocol.Location
+		switch {
+		case positionArg != nil:
+			if fileURI == "" {
+				return nil, errors.New("file_uri is required with a position")
+			}
+			if !strings.HasPrefix(fileURI, "file://") {
+				fileURI = convertPathToURI(fileURI)
+			}
+			pos, err := parsePosition(positionArg)
+			if err != nil {
+				return nil, err
+			}
+			definition = protocol.Location{URI: fileURI, Range: protocol.Range{Start: pos, End: pos}}
+			locations, err := lspClient.GoToDefinition(fileURI, pos.Line, pos.Character)
+			if err != nil {
+				return nil, t.handleLSPError(err)
+			}
+			if len(locations) > 0 {
+				definition = locations[0]
+			}
+		case name != "":
+			symbol, err := resolveSymbol(lspClient, name)
+			if err != nil {
+				return nil, t.handleLSPError(err)
+			}
+			definition = symbol.Location
+		default:
+			return nil, errors.New("either name or file_uri and position are required")
+		}
+
+		result, err := symbolContext(lspClient, definition, maxSites)
+		if err != nil {
+			return nil, t.handleLSPError(err)
+		}
+		fitTokenBudget(result, maxTokens)
+
+		data, err := json.Marshal(result)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal result: %w", err)
+		}
+
+		return mcp.NewToolResultText(string(data)), nil
+	})
+}
+
+// symbolContext gathers the context of the symbol declared at the location
+func symbolContext(lspClient client.LSPClient, definition protocol.Location, maxSites int) (*symbolContextResult, error) {
+	uri, pos := definition.URI, definition.Range.Start
+	cache := newSymbolCache(lspClient)
+	result := &symbolContextResult{
+		Definition: definition,
+		CallSites:  []contextSite{},
+	}
+
+	var lines []string
+	if content, err := os.ReadFile(protocol.URIToPath(uri)); err == nil {
+		lines = strings.Split(string(content), "\n")
+	}
+
+	symbols, err := cache.get(uri)
+	if err != nil {
+		return nil, err
+	}
+	if symbol := innermostSymbol(symbols, pos); symbol != nil {
+		result.Name = symbol.Name
+		result.Kind = symbol.Kind.String()
+		if symbol.Range.End.Line < len(lines) {
+			result.SourceLine = symbol.Range.Start.Line
+			result.Source = strings.Join(lines[symbol.Range.Start.Line:symbol.Range.End.Line+1], "\n")
+		}
+	}
+
+	if hover, err := lspClient.GetHover(uri, pos.Line, pos.Character); err == nil {
+		result.Documentation = hover
+	}
+
+	references, err := lspClient.FindReferences(uri, pos.Line, pos.Character, false)
+	if err != nil {
+		return nil, err
+	}
+	sortLocations(references)
+	result.ReferenceCount = len(references)
+
+	files := make(map[string]bool)
+	sampled := make(map[string]bool)
+	for _, ref := range references {
+		files[ref.URI] = true
+	}
+	result.ReferenceFiles = len(files)
+
+	// Sample call sites from as many files as possible, then fill up
+	for _, distinctFiles := range []bool{true, false} {
+		for _, ref := range references {
+			if len(result.CallSites) >= maxSites {
+				break
+			}
+			key := fmt.Sprintf("%s:%d", ref.URI, ref.Range.Start.Line)
+			if sampled[key] || (distinctFiles && sampled[ref.URI]) {
+				continue
+			}
+			site, err := siteAt(cache, ref)
+			if err != nil {
+				return nil, err
+			}
+			sampled[key] = true
+			sampled[ref.URI] = true
+			result.CallSites = append(result.CallSites, site)
+		}
+	}
+
+	// gopls only answers implementation requests for types and methods
+	if implementations, err := lspClient.GetImplementations(uri, pos.Line, pos.Character); err == nil {
+		sortLocations(implementations)
+		for _, impl := range implementations {
+			site, err := siteAt(cache, impl)
+			if err != nil {
+				return nil, err
+			}
+			result.Implementations = append(result.Implementations, site)
+		}
+	}
+
+	return result, nil
+}
+
+// siteAt describes a location by its line and enclosing declaration
+func siteAt(cache *symbolCache, location protocol.Location) (contextSite, error) {
+	site := contextSite{URI: location.URI, Line: location.Range.Start.Line}
+
+	symbols, err := cache.get(location.URI)
+	if err != nil {
+		return site, err
+	}
+	if symbol := innermostSymbol(symbols, location.Range.Start); symbol != nil {
+		site.Symbol = symbol.Name
+	}
+
+	site.Text = strings.TrimSpace(cache.line(location.URI, site.Line))
+	return site, nil
+}
+
+// fitTokenBudget shortens the result until its estimated size fits in
+// maxTokens: the source first, then the call sites, the implementations and
+// finally the documentation
+func fitTokenBudget(result *symbolContextResult, maxTokens int) {
+	size := func() int {
+		data, _ := json.Marshal(result)
+		return estimateTokens(string(data))
+	}
+	truncated := func(part string) {
+		for _, p := range result.Truncated {
+			if p == part {
+				return
+			}
+		}
+		result.Truncated = append(result.Truncated, part)
+	}
+
+	for size() > maxTokens && result.Source != "" {
+		lines := strings.Split(result.Source, "\n")
+		if len(lines) <= 2 {
+			result.Source = ""
+		} else {
+			result.Source = strings.Join(lines[:len(lines)/2], "\n") + "\n..."
+		}
+		truncated("source")
+	}
+	for size() > maxTokens && len(result.CallSites) > 0 {
+		result.CallSites = result.CallSites[:len(result.CallSites)-1]
+		truncated("call_sites")
+	}
+	for size() > maxTokens && len(result.Implementations) > 0 {
+		result.Implementations = result.Implementations[:len(result.Implementations)-1]
+		truncated("implementations")
+	}
+	for size() > maxTokens && len(result.Documentation) > 200 {
+		cut := len(result.Documentation) / 2
+		for cut > 0 && !utf8.RuneStart(result.Documentation[cut]) {
+			cut--
+		}
+		result.Documentation = result.Documentation[:cut] + "..."
+		truncated("documentation")
+	}
+
+	result.EstimatedTokens = size()
+}
@@ -0,0 +1,124 @@
+package tools
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/solatis/mcp-gopls/pkg/lsp/protocol"
+)
+
+// fakeSymbolClient also answers document symbol requests from a map,
+// counting the requests
+type fakeSymbolClient struct {
+	fakeWorkspaceClient
+	documents map[string][]protocol.DocumentSymbol
+	requests  int
+}
+
+func (c *fakeSymbolClient) GetDocumentSymbols(uri string) ([]protocol.DocumentSymbol, error) {
+	c.requests++
+	return c.documents[uri], nil
+}
+
+func TestResolveSymbolPrefersNonTestFiles(t *testing.T) {
+	testFirst := []protocol.SymbolInformation{
+		symbolIn("Server", "example.com/app/server", "file:///src/app/server/server_test.go"),
+		symbolIn("Server", "example.com/app/server", "file:///src/app/server/server.go"),
+	}
+
+	tests := []struct {
+		name      string
+		workspace []protocol.SymbolInformation
+		want      string
+		wantErr   bool
+	}{
+		{
+			name:      "test file reported first",
+			workspace: testFirst,
+			want:      "file:///src/app/server/server.go",
+		},
+		{
+			name:      "test file reported last",
+			workspace: []protocol.SymbolInformation{testFirst[1], testFirst[0]},
+			want:      "file:///src/app/server/server.go",
+		},
+		{
+			name:      "only in a test file",
+			workspace: testFirst[:1],
+			want:      "file:///src/app/server/server_test.go",
+		},
+		{
+			name: "ambiguous test files",
+			workspace: []protocol.SymbolInformation{
+				symbolIn("Server", "example.com/app/a", "file:///src/app/a/a_test.go"),
+				symbolIn("Server", "example.com/app/b", "file:///src/app/b/b_test.go"),
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			symbol, err := resolveSymbol(&fakeWorkspaceClient{workspace: tt.workspace}, "server.Server")
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("resolved %s, want an ambiguity error", symbol.Location.URI)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if symbol.Location.URI != tt.want {
+				t.Errorf("resolved %s, want %s", symbol.Location.URI, tt.want)
+			}
+		})
+	}
+}
+
+func TestSiteAtReadsFilesOnce(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "server.go")
+	src := "package server\n\nfunc (s *Server) Start() {\n\ts.listen()\n\ts.serve()\n}\n"
+	if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	uri := convertPathToURI(path)
+
+	fake := &fakeSymbolClient{documents: map[string][]protocol.DocumentSymbol{
+		uri: {{
+			Name:  "(*Server).Start",
+			Kind:  protocol.SKMethod,
+			Range: protocol.Range{Start: protocol.Position{Line: 2}, End: protocol.Position{Line: 5, Character: 1}},
+		}},
+	}}
+	cache := newSymbolCache(fake)
+
+	at := func(line int) contextSite {
+		site, err := siteAt(cache, protocol.Location{URI: uri, Range: protocol.Range{Start: protocol.Position{Line: line, Character: 1}}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return site
+	}
+
+	first := at(3)
+	if first.Symbol != "(*Server).Start" || first.Text != "s.listen()" {
+		t.Errorf("first site = %+v", first)
+	}
+
+	// Later sites in the same call come from the cache, not from the file
+	if err := os.WriteFile(path, []byte("package server\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if second := at(4); second.Text != "s.serve()" {
+		t.Errorf("second site = %+v", second)
+	}
+	if fake.requests != 1 {
+		t.Errorf("%d document symbol requests, want 1", fake.requests)
+	}
+
+	if beyond := at(100); beyond.Text != "" {
+		t.Errorf("site past the end of the file = %+v", beyond)
+	}
+}
@@ -0,0 +1,117 @@
+package tools
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/solatis/mcp-gopls/pkg/lsp/protocol"
+)
+
+// symbolFilter narrows down workspace symbol results on the client side.
+type symbolFilter struct {
+	Kinds         map[protocol.SymbolKind]bool
+	PackagePrefix string
+	ExportedOnly  bool
+	ExcludeTests  bool
+	ExcludeVendor bool
+}
+
+func parseSymbolKinds(names []string) (map[protocol.SymbolKind]bool, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+
+	kinds := make(map[protocol.SymbolKind]bool, len(names))
+	for _, name := range names {
+		kind, ok := protocol.ParseSymbolKind(name)
+		if !ok {
+			return nil, fmt.Errorf("unknown symbol kind: %s", name)
+		}
+		kinds[kind] = true
+	}
+	return kinds, nil
+}
+
+func (f symbolFilter) match(symbol protocol.SymbolInformation) bool {
+	if len(f.Kinds) > 0 && !f.Kinds[symbol.Kind] {
+		return false
+	}
+
+	// gopls reports the package path of workspace symbols as container name.
+	if f.PackagePrefix != "" && !strings.HasPrefix(symbol.ContainerName, f.PackagePrefix) {
+		return false
+	}
+
+	if f.ExportedOnly && !isExportedSymbol(symbol.Name) {
+		return false
+	}
+
+	path := filepath.ToSlash(protocol.URIToPath(symbol.Location.URI))
+	if f.ExcludeTests && strings.HasSuffix(path, "_test.go") {
+		return false
+	}
+	if f.ExcludeVendor && strings.Contains(path, "/vendor/") {
+		return false
+	}
+
+	return true
+}
+
+func (f symbolFilter) apply(symbols []protocol.SymbolInformation) []protocol.SymbolInformation {
+	filtered := make([]protocol.SymbolInformation, 0, len(symbols))
+	for _, symbol := range symbols {
+		if f.match(symbol) {
+			filtered = append(filtered, symbol)
+		}
+	}
+	return filtered
+}
+
+// isExportedSymbol reports whether the last component of a possibly qualified
+// symbol name (e.g. "pkg.Type.Method") is exported.
+func isExportedSymbol(name string) bool {
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		name = name[i+1:]
+	}
+	r, _ := utf8.DecodeRuneInString(name)
+	return unicode.IsUpper(r)
+}
+
+func symbolFilterOptions() []mcp.ToolOption {
+	kindNames := make([]string, 0, protocol.SKTypeParameter)
+	for kind := protocol.SKFile; kind <= protocol.SKTypeParameter; kind++ {
+		kindNames = append(kindNames, kind.String())
+	}
+
+	return []mcp.ToolOption{
+		mcp.WithArray("kinds",
+			mcp.Description("Only return symbols of these kinds, e.g. [\"function\", \"method\", \"struct\", \"interface\"]"),
+			mcp.Items(map[string]any{"type": "string", "enum": kindNames}),
+		),
+		mcp.WithString("package_prefix",
+			mcp.Description("Only return symbols whose package import path starts with this prefix, e.g. 'github.com/org/repo/pkg'"),
+		),
+		mcp.WithBoolean("exported_only",
+			mcp.Description("Only return exported symbols"),
+		),
+		mcp.WithBoolean("exclude_tests",
+			mcp.Description("Exclude symbols declared in _test.go files"),
+		),
+		mcp.WithBoolean("exclude_vendor",
+			mcp.Description("Exclude symbols declared in vendor directories"),
+		),
+		mcp.WithString("symbol_matcher",
+			mcp.Description("gopls symbolMatcher setting for this query: how the query matches symbol names, 'fuzzy' (default), 'fastfuzzy', 'casesensitive' or 'caseinsensitive'"),
+			mcp.Enum("fuzzy", "fastfuzzy", "casesensitive", "caseinsensitive"),
+		),
+		mcp.WithString("symbol_scope",
+			mcp.Description("gopls symbolScope setting for this query: 'workspace' searches only workspace packages, 'all' (default) also the standard library and dependencies"),
+			mcp.Enum("workspace", "all"),
+		),
+	}
+}
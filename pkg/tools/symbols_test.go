@@ -0,0 +1,80 @@
+package tools
+
+import (
+	"testing"
+
+	"github.com/solatis/mcp-gopls/pkg/lsp/protocol"
+)
+
+func TestSymbolFilter(t *testing.T) {
+	symbol := func(name string, kind protocol.SymbolKind, container, path string) protocol.SymbolInformation {
+		return protocol.SymbolInformation{
+			Name:          name,
+			Kind:          kind,
+			ContainerName: container,
+			Location:      protocol.Location{URI: "file://" + path},
+		}
+	}
+
+	server := symbol("Server", protocol.SKStruct, "example.com/app/server", "/src/app/server/server.go")
+	start := symbol("Server.Start", protocol.SKMethod, "example.com/app/server", "/src/app/server/server.go")
+	helper := symbol("newTestServer", protocol.SKFunction, "example.com/app/server", "/src/app/server/server_test.go")
+	vendored := symbol("ServeMux", protocol.SKStruct, "example.com/lib", "/src/app/vendor/example.com/lib/mux.go")
+
+	tests := []struct {
+		name   string
+		filter symbolFilter
+		symbol protocol.SymbolInformation
+		want   bool
+	}{
+		{"empty filter", symbolFilter{}, helper, true},
+		{"kind matches", symbolFilter{Kinds: map[protocol.SymbolKind]bool{protocol.SKStruct: true}}, server, true},
+		{"kind differs", symbolFilter{Kinds: map[protocol.SymbolKind]bool{protocol.SKStruct: true}}, start, false},
+		{"package prefix matches", symbolFilter{PackagePrefix: "example.com/app"}, start, true},
+		{"package prefix differs", symbolFilter{PackagePrefix: "example.com/app"}, vendored, false},
+		{"exported method", symbolFilter{ExportedOnly: true}, start, true},
+		{"unexported function", symbolFilter{ExportedOnly: true}, helper, false},
+		{"test file excluded", symbolFilter{ExcludeTests: true}, helper, false},
+		{"non-test file kept", symbolFilter{ExcludeTests: true}, server, true},
+		{"vendor excluded", symbolFilter{ExcludeVendor: true}, vendored, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.filter.match(tt.symbol); got != tt.want {
+				t.Errorf("match(%s) = %v, want %v", tt.symbol.Name, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseSymbolKinds(t *testing.T) {
+	kinds, err := parseSymbolKinds([]string{"Function", "struct"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(kinds) != 2 || !kinds[protocol.SKFunction] || !kinds[protocol.SKStruct] {
+		t.Errorf("parseSymbolKinds = %v", kinds)
+	}
+
+	if _, err := parseSymbolKinds([]string{"functor"}); err == nil {
+		t.Error("parseSymbolKinds accepted an unknown kind")
+	}
+}
+
+func TestIsExportedSymbol(t *testing.T) {
+	tests := map[string]bool{
+		"Server":           true,
+		"server":           false,
+		"Server.Start":     true,
+		"Server.start":     false,
+		"pkg.Server.Start": true,
+		"Édouard":          true,
+		"_":                false,
+	}
+	for name, want := range tests {
+		if got := isExportedSymbol(name); got != want {
+			t.Errorf("isExportedSymbol(%q) = %v, want %v", name, got, want)
+		}
+	}
+}
@@ -0,0 +1,383 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/solatis/mcp-gopls/pkg/lsp/client"
+	"github.com/solatis/mcp-gopls/pkg/lsp/protocol"
+)
+
+const (
+	testKindTest      = "test"
+	testKindBenchmark = "benchmark"
+	testKindFuzz      = "fuzz"
+	testKindExample   = "example"
+)
+
+// testFunction is a test, benchmark, fuzz test or example function
+type testFunction struct {
+	Name string `json:"name"`
+	Kind string `json:"kind"`
+	File string `json:"file"`
+	Line int    `json:"line"`
+	// Via is the function calling the symbol that the test calls, for tests
+	// that only reach the symbol through one level of callers
+	Via string `json:"via,omitempty"`
+}
+
+// testSelection gives the run_tests arguments selecting the found tests of a
+// package
+type testSelection struct {
+	Dir   string `json:"dir"`
+	Run   string `json:"run,omitempty"`
+	Bench string `json:"bench,omitempty"`
+}
+
+type testDiscoveryResult struct {
+	Tests     []testFunction  `json:"tests"`
+	Selection []testSelection `json:"run_tests"`
+}
+
+// testFunctionKind classifies a top-level function name following the rules
+// of "go test", returning "" for other functions
+func testFunctionKind(name string) string {
+	hasSuffix := func(prefix string) bool {
+		if !strings.HasPrefix(name, prefix) {
+			return false
+		}
+		if len(name) == len(prefix) {
+			return true
+		}
+		r, _ := utf8.DecodeRuneInString(name[len(prefix):])
+		return !unicode.IsLower(r)
+	}
+
+	switch {
+	case name == "TestMain":
+		return ""
+	case hasSuffix("Test"):
+		return testKindTest
+	case hasSuffix("Benchmark"):
+		return testKindBenchmark
+	case hasSuffix("Fuzz"):
+		return testKindFuzz
+	case strings.HasPrefix(name, "Example"):
+		return testKindExample
+	default:
+		return ""
+	}
+}
+
+// symbolCache caches document symbols and file lines for the duration of a
+// tool call
+type symbolCache struct {
+	client  client.LSPClient
+	symbols map[string][]protocol.DocumentSymbol
+	lines   map[string][]string
+}
+
+func newSymbolCache(lspClient client.LSPClient) *symbolCache {
+	return &symbolCache{
+		client:  lspClient,
+		symbols: make(map[string][]protocol.DocumentSymbol),
+		lines:   make(map[string][]string),
+	}
+}
+
+// line returns a 0-indexed line of a file, or "" if the file cannot be read
+// or is shorter
+func (c *symbolCache) line(uri string, n int) string {
+	lines, ok := c.lines[uri]
+	if !ok {
+		if content, err := os.ReadFile(protocol.URIToPath(uri)); err == nil {
+			lines = strings.Split(string(content), "\n")
+		}
+		c.lines[uri] = lines
+	}
+	if n < 0 || n >= len(lines) {
+		return ""
+	}
+	return lines[n]
+}
+
+func (c *symbolCache) get(uri string) ([]protocol.DocumentSymbol, error) {
+	if symbols, ok := c.symbols[uri]; ok {
+		return symbols, nil
+	}
+
+	symbols, err := c.client.GetDocumentSymbols(uri)
+	if err != nil {
+		return nil, err
+	}
+	c.symbols[uri] = symbols
+	return symbols, nil
+}
+
+// enclosing returns the top-level symbol (function, method, type...) whose
+// range contains the position
+func (c *symbolCache) enclosing(uri string, pos protocol.Position) (*protocol.DocumentSymbol, error) {
+	symbols, err := c.get(uri)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range symbols {
+		if rangeContains(symbols[i].Range, pos) {
+			return &symbols[i], nil
+		}
+	}
+	return nil, nil
+}
+
+func rangeContains(r protocol.Range, pos protocol.Position) bool {
+	if pos.Line < r.Start.Line || pos.Line > r.End.Line {
+		return false
+	}
+	if pos.Line == r.Start.Line && pos.Character < r.Start.Character {
+		return false
+	}
+	if pos.Line == r.End.Line && pos.Character > r.End.Character {
+		return false
+	}
+	return true
+}
+
+func (t *LSPTools) registerFindTests(s *server.MCPServer) {
+	findTestsTool := mcp.NewTool("find_tests",
+		mcp.WithDescription("FIND THE RIGHT TESTS TO RUN: Use this tool to list the Test, Benchmark, Fuzz and Example functions of a package, or, given a symbol position, to find the test functions that reference the symbol directly or through one level of callers. Use this when: 1) After changing a function, to know which tests exercise it, 2) User asks 'what tests cover X?', 3) Exploring the tests of a package. Returns the test functions with their locations, plus ready-to-use 'dir'/'run'/'bench' arguments for run_tests."),
+		mcp.WithString("dir",
+			mcp.Description("Package directory (or a file in it) whose tests to list, as a path or file:// URI. Used when no position is given"),
+		),
+		mcp.WithString("file_uri",
+			mcp.Description("URI or absolute path of the file containing the symbol to find tests for"),
+		),
+		mcp.WithObject("position",
+			mcp.Description("Position of the symbol to find tests for. Must contain 'line' (0-indexed line number) and 'character' (0-indexed column number) keys"),
+		),
+	)
+
+	s.AddTool(findTestsTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		lspClient := t.getClient()
+		if lspClient == nil {
+			return nil, errors.New("LSP client not available")
+		}
+		cache := newSymbolCache(lspClient)
+
+		var tests []testFunction
+		var err error
+
+		if positionArg := request.GetArguments()["position"]; positionArg != nil {
+			fileURI := request.GetString("file_uri", "")
+			if fileURI == "" {
+				return nil, errors.New("file_uri is required with position")
+			}
+			if !strings.HasPrefix(fileURI, "file://") {
+				fileURI = convertPathToURI(fileURI)
+			}
+
+			var position protocol.Position
+			if position, err = parsePosition(positionArg); err != nil {
+				return nil, err
+			}
+
+			tests, err = testsForSymbol(lspClient, cache, fileURI, position)
+		} else {
+			dir := request.GetString("dir", "")
+			if dir == "" {
+				return nil, errors.New("either dir or file_uri and position are required")
+			}
+			tests, err = listPackageTests(cache, resolveDir(dir))
+		}
+		if err != nil {
+			return nil, t.handleLSPError(err)
+		}
+
+		result, err := json.Marshal(testDiscoveryResult{
+			Tests:     tests,
+			Selection: selectTests(tests),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal result: %w", err)
+		}
+
+		return mcp.NewToolResultText(string(result)), nil
+	})
+}
+
+// listPackageTests returns the test functions declared in the _test.go files
+// of a directory
+func listPackageTests(cache *symbolCache, dir string) ([]testFunction, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read package directory: %w", err)
+	}
+
+	tests := []testFunction{}
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), "_test.go") {
+			continue
+		}
+
+		path := filepath.Join(dir, entry.Name())
+		symbols, err := cache.get(convertPathToURI(path))
+		if err != nil {
+			return nil, err
+		}
+
+		for _, symbol := range symbols {
+			if symbol.Kind != protocol.SKFunction {
+				continue
+			}
+			if kind := testFunctionKind(symbol.Name); kind != "" {
+				tests = append(tests, testFunction{
+					Name: symbol.Name,
+					Kind: kind,
+					File: path,
+					Line: symbol.SelectionRange.Start.Line,
+				})
+			}
+		}
+	}
+
+	return tests, nil
+}
+
+// testsForSymbol returns the test functions referencing the symbol at the
+// position, directly or through a non-test function calling it
+func testsForSymbol(lspClient client.LSPClient, cache *symbolCache, uri string, pos protocol.Position) ([]testFunction, error) {
+	references, err := lspClient.FindReferences(uri, pos.Line, pos.Character, false)
+	if err != nil {
+		return nil, err
+	}
+
+	tests := []testFunction{}
+	seen := make(map[string]bool)
+	callers := make(map[string]*protocol.DocumentSymbol)
+	var callerURIs []string
+
+	addTest := func(refURI string, symbol *protocol.DocumentSymbol, via string) {
+		key := refURI + "#" + symbol.Name
+		if seen[key] {
+			return
+		}
+		seen[key] = true
+		tests = append(tests, testFunction{
+			Name: symbol.Name,
+			Kind: testFunctionKind(symbol.Name),
+			File: protocol.URIToPath(refURI),
+			Line: symbol.SelectionRange.Start.Line,
+			Via:  via,
+		})
+	}
+
+	for _, ref := range references {
+		symbol, err := cache.enclosing(ref.URI, ref.Range.Start)
+		if err != nil {
+			return nil, err
+		}
+		if symbol == nil || (symbol.Kind != protocol.SKFunction && symbol.Kind != protocol.SKMethod) {
+			continue
+		}
+
+		if strings.HasSuffix(ref.URI, "_test.go") && symbol.Kind == protocol.SKFunction && testFunctionKind(symbol.Name) != "" {
+			addTest(ref.URI, symbol, "")
+			continue
+		}
+
+		key := ref.URI + "#" + symbol.Name
+		if _, ok := callers[key]; !ok {
+			callers[key] = symbol
+			callerURIs = append(callerURIs, key)
+		}
+	}
+
+	// One level of callers: tests referencing the functions that reference
+	// the symbol
+	for _, key := range callerURIs {
+		caller := callers[key]
+		callerURI := key[:strings.LastIndex(key, "#")]
+		start := caller.SelectionRange.Start
+
+		callerRefs, err := lspClient.FindReferences(callerURI, start.Line, start.Character, false)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, ref := range callerRefs {
+			if !strings.HasSuffix(ref.URI, "_test.go") {
+				continue
+			}
+			symbol, err := cache.enclosing(ref.URI, ref.Range.Start)
+			if err != nil {
+				return nil, err
+			}
+			if symbol != nil && symbol.Kind == protocol.SKFunction && testFunctionKind(symbol.Name) != "" {
+				addTest(ref.URI, symbol, caller.Name)
+			}
+		}
+	}
+
+	return tests, nil
+}
+
+// selectTests builds run_tests arguments selecting the tests, per package
+// directory
+func selectTests(tests []testFunction) []testSelection {
+	runs := make(map[string][]string)
+	benches := make(map[string][]string)
+	seen := make(map[string]bool)
+	var dirs []string
+
+	for _, test := range tests {
+		dir := filepath.Dir(test.File)
+		if !seen[dir] {
+			seen[dir] = true
+			dirs = append(dirs, dir)
+		}
+
+		name := regexp.QuoteMeta(test.Name)
+		if test.Kind == testKindBenchmark {
+			benches[dir] = appendUnique(benches[dir], name)
+		} else {
+			runs[dir] = appendUnique(runs[dir], name)
+		}
+	}
+
+	selections := []testSelection{}
+	for _, dir := range dirs {
+		selection := testSelection{Dir: dir}
+		if names := runs[dir]; len(names) > 0 {
+			sort.Strings(names)
+			selection.Run = "^(" + strings.Join(names, "|") + ")$"
+		}
+		if names := benches[dir]; len(names) > 0 {
+			sort.Strings(names)
+			selection.Bench = "^(" + strings.Join(names, "|") + ")$"
+		}
+		selections = append(selections, selection)
+	}
+
+	return selections
+}
+
+func appendUnique(values []string, value string) []string {
+	for _, v := range values {
+		if v == value {
+			return values
+		}
+	}
+	return append(values, value)
+}
@@ -0,0 +1,124 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/solatis/mcp-gopls/pkg/gocmd"
+)
+
+// defaultTestTimeout bounds a run_tests call when no timeout is given
+const defaultTestTimeout = 5 * time.Minute
+
+func (t *LSPTools) registerRunTests(s *server.MCPServer) {
+	runTestsTool := mcp.NewTool("run_tests",
+		mcp.WithDescription("RUN GO TESTS WITH STRUCTURED RESULTS: Use this tool to run the tests or benchmarks of one or more packages and get pass/fail/skip per test, durations, failure output and failure locations as file:line. Much easier to act on than raw 'go test' output. Use this when: 1) After a change, to run the tests covering it, 2) User asks 'do the tests pass?', 3) Reproducing a failing test, 4) Measuring a benchmark. Runs 'go test -json' with the build flags and environment gopls is configured with."),
+		mcp.WithString("dir",
+			mcp.Required(),
+			mcp.Description("Directory (or a file in it) to run the tests from, as a path or file:// URI. Usually the package directory"),
+		),
+		mcp.WithString("packages",
+			mcp.Description("Package pattern relative to dir, e.g. '.', './...' or an import path (default '.')"),
+		),
+		mcp.WithString("run",
+			mcp.Description("Only run tests, examples and fuzz tests matching this regular expression, as 'go test -run', e.g. '^TestParse$'"),
+		),
+		mcp.WithString("bench",
+			mcp.Description("Run benchmarks matching this regular expression, as 'go test -bench'. Tests are skipped unless 'run' is also given"),
+		),
+		mcp.WithBoolean("short",
+			mcp.Description("Pass -short to skip long-running tests"),
+		),
+		mcp.WithBoolean("race",
+			mcp.Description("Enable the race detector"),
+		),
+		mcp.WithString("timeout",
+			mcp.Description("Maximum duration of the run, e.g. '30s' or '10m' (default '5m')"),
+		),
+	)
+
+	s.AddTool(runTestsTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		dir := request.GetString("dir", "")
+		if dir == "" {
+			return nil, errors.New("dir is required")
+		}
+		dir = resolveDir(dir)
+
+		timeout := defaultTestTimeout
+		if value := request.GetString("timeout", ""); value != "" {
+			parsed, err := time.ParseDuration(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid timeout: %w", err)
+			}
+			timeout = parsed
+		}
+
+		pattern := request.GetString("packages", ".")
+		run := request.GetString("run", "")
+		bench := request.GetString("bench", "")
+
+		args := []string{"-json", "-timeout", timeout.String()}
+		if bench != "" {
+			args = append(args, "-bench", bench)
+			if run == "" {
+				run = "^$"
+			}
+		}
+		if run != "" {
+			args = append(args, "-run", run)
+		}
+		if request.GetBool("short", false) {
+			args = append(args, "-short")
+		}
+		if request.GetBool("race", false) {
+			args = append(args, "-race")
+		}
+		args = append(args, pattern)
+
+		runner := t.goRunner()
+
+		// Give the go command a little more time than the test binary, so
+		// that the test timeout reports the hanging test.
+		ctx, cancel := context.WithTimeout(ctx, timeout+30*time.Second)
+		defer cancel()
+
+		packageDirs := make(map[string]string)
+		if packages, err := runner.ListPackages(ctx, dir, false, pattern); err == nil {
+			for _, pkg := range packages {
+				packageDirs[pkg.ImportPath] = pkg.Dir
+			}
+		}
+
+		stdout, stderr, runErr := runner.Run(ctx, dir, "test", args...)
+
+		report, err := gocmd.ParseTestEvents(strings.NewReader(string(stdout)), packageDirs)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse test output: %w", err)
+		}
+		if runErr != nil && len(report.Tests) == 0 && len(report.Packages) == 0 {
+			return nil, fmt.Errorf("%w: %s", runErr, strings.TrimSpace(string(stderr)))
+		}
+
+		result, err := json.Marshal(report)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal result: %w", err)
+		}
+
+		return mcp.NewToolResultText(string(result)), nil
+	})
+}
+
+// goRunner returns a go command runner using the current gopls settings
+func (t *LSPTools) goRunner() *gocmd.Runner {
+	if lspClient := t.getClient(); lspClient != nil {
+		return gocmd.NewRunner(lspClient.Settings())
+	}
+	return gocmd.NewRunner(nil)
+}
@@ -0,0 +1,230 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/solatis/mcp-gopls/pkg/lsp/protocol"
+)
+
+const (
+	// diagnosticsQuietPeriod is how long gopls must stay silent before the
+	// workspace diagnostics are considered complete
+	diagnosticsQuietPeriod = time.Second
+	// workspaceDiagnosticsTimeout bounds the wait for gopls to finish
+	// diagnosing the workspace
+	workspaceDiagnosticsTimeout = 30 * time.Second
+)
+
+// diagnosticFilter selects diagnostics by severity, source and file path
+type diagnosticFilter struct {
+	MinSeverity protocol.DiagnosticSeverity
+	Sources     []string
+	PathPattern string
+	Dir         string
+}
+
+func (f diagnosticFilter) matchPath(path string) bool {
+	if f.Dir != "" && path != f.Dir && !strings.HasPrefix(path, f.Dir+string(filepath.Separator)) {
+		return false
+	}
+
+	if f.PathPattern == "" {
+		return true
+	}
+	if strings.ContainsAny(f.PathPattern, "*?[") {
+		if ok, _ := filepath.Match(f.PathPattern, path); ok {
+			return true
+		}
+		ok, _ := filepath.Match(f.PathPattern, filepath.Base(path))
+		return ok
+	}
+	return strings.Contains(path, f.PathPattern)
+}
+
+func (f diagnosticFilter) match(d protocol.Diagnostic) bool {
+	// Diagnostics without severity are treated as errors, as in the spec
+	severity := protocol.DiagnosticSeverity(d.Severity)
+	if severity == 0 {
+		severity = protocol.SeverityError
+	}
+	if f.MinSeverity != 0 && severity > f.MinSeverity {
+		return false
+	}
+
+	if len(f.Sources) > 0 {
+		matched := false
+		for _, source := range f.Sources {
+			if strings.EqualFold(source, d.Source) || strings.HasPrefix(strings.ToLower(d.Source), strings.ToLower(source)) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+
+	return true
+}
+
+// fileDiagnosticsSummary holds the diagnostics of a file with their count
+type fileDiagnosticsSummary struct {
+	URI         string                `json:"uri"`
+	Count       int                   `json:"count"`
+	Diagnostics []protocol.Diagnostic `json:"diagnostics,omitempty"`
+}
+
+type workspaceDiagnosticsResult struct {
+	Total      int                      `json:"total"`
+	BySeverity map[string]int           `json:"by_severity"`
+	BySource   map[string]int           `json:"by_source"`
+	Files      []fileDiagnosticsSummary `json:"files"`
+}
+
+func (t *LSPTools) registerWorkspaceDiagnostics(s *server.MCPServer) {
+	workspaceDiagnosticsTool := mcp.NewTool("workspace_diagnostics",
+		mcp.WithDescription("IS THE REPO HEALTHY? Use this LSP tool to get every diagnostic gopls knows for all packages of the workspace in one call, instead of one check_diagnostics call per file. Filter by severity, source (e.g. 'compiler', a vet analyzer like 'printf', or staticcheck checks like 'SA') and path pattern. Returns counts per severity, source and file, with the diagnostics of each file."),
+		mcp.WithString("dir",
+			mcp.Description("Directory of the module or package to report on, as a path or file:// URI. gopls is made to load it if needed, and only diagnostics of files under it are returned"),
+		),
+		mcp.WithString("min_severity",
+			mcp.Description("Least severe diagnostics to include (default 'hint', i.e. all)"),
+			mcp.Enum("error", "warning", "info", "hint"),
+		),
+		mcp.WithArray("sources",
+			mcp.Description("Only include diagnostics whose source equals or starts with one of these, e.g. [\"compiler\", \"printf\", \"SA\"]"),
+			mcp.Items(map[string]any{"type": "string"}),
+		),
+		mcp.WithString("path_pattern",
+			mcp.Description("Only include files whose path contains this string, or matches it when it is a glob like '*_test.go'"),
+		),
+		mcp.WithBoolean("summary_only",
+			mcp.Description("Only return counts, without the diagnostics themselves"),
+		),
+	)
+
+	s.AddTool(workspaceDiagnosticsTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		filter := diagnosticFilter{
+			Sources:     request.GetStringSlice("sources", nil),
+			PathPattern: request.GetString("path_pattern", ""),
+		}
+
+		if name := request.GetString("min_severity", ""); name != "" {
+			severity, ok := protocol.ParseDiagnosticSeverity(name)
+			if !ok {
+				return nil, fmt.Errorf("unknown severity: %s", name)
+			}
+			filter.MinSeverity = severity
+		}
+
+		lspClient := t.getClient()
+		if lspClient == nil {
+			return nil, errors.New("LSP client not available")
+		}
+
+		if dir := request.GetString("dir", ""); dir != "" {
+			filter.Dir = resolveDir(dir)
+
+			// Opening a file makes gopls load and diagnose its module
+			if goFile := findGoFile(filter.Dir); goFile != "" {
+				if _, err := lspClient.GetDiagnostics(convertPathToURI(goFile)); err != nil {
+					return nil, t.handleLSPError(err)
+				}
+			}
+		}
+		lspClient.WaitForDiagnosticsIdle(diagnosticsQuietPeriod, workspaceDiagnosticsTimeout)
+
+		result := collectWorkspaceDiagnostics(lspClient.WorkspaceDiagnostics(), filter, request.GetBool("summary_only", false))
+
+		data, err := json.Marshal(result)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal result: %w", err)
+		}
+
+		return mcp.NewToolResultText(string(data)), nil
+	})
+}
+
+func collectWorkspaceDiagnostics(all map[string][]protocol.Diagnostic, filter diagnosticFilter, summaryOnly bool) workspaceDiagnosticsResult {
+	result := workspaceDiagnosticsResult{
+		BySeverity: make(map[string]int),
+		BySource:   make(map[string]int),
+		Files:      []fileDiagnosticsSummary{},
+	}
+
+	uris := make([]string, 0, len(all))
+	for uri := range all {
+		uris = append(uris, uri)
+	}
+	sort.Strings(uris)
+
+	for _, uri := range uris {
+		if !filter.matchPath(protocol.URIToPath(uri)) {
+			continue
+		}
+
+		summary := fileDiagnosticsSummary{URI: uri}
+		for _, d := range all[uri] {
+			if !filter.match(d) {
+				continue
+			}
+
+			summary.Count++
+			severity := protocol.DiagnosticSeverity(d.Severity)
+			if severity == 0 {
+				severity = protocol.SeverityError
+			}
+			result.BySeverity[severity.String()]++
+			result.BySource[d.Source]++
+			if !summaryOnly {
+				summary.Diagnostics = append(summary.Diagnostics, d)
+			}
+		}
+
+		if summary.Count > 0 {
+			result.Total += summary.Count
+			result.Files = append(result.Files, summary)
+		}
+	}
+
+	return result
+}
+
+// findGoFile returns a non-test Go file in dir or its subdirectories, or ""
+func findGoFile(dir string) string {
+	var found string
+	filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			// Skip unreadable directories, but not the siblings of an
+			// unreadable file
+			if entry == nil || entry.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if entry.IsDir() {
+			name := entry.Name()
+			if path != dir && (name == "vendor" || name == "testdata" || strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_")) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if strings.HasSuffix(path, ".go") && !strings.HasSuffix(path, "_test.go") {
+			found = path
+			return filepath.SkipAll
+		}
+		return nil
+	})
+	return found
+}